@@ -120,6 +120,8 @@ type Config struct {
 	UserProjectOverride                bool
 	RequestReason                      string
 	RequestTimeout                     time.Duration
+	RetainRulesets                     int
+	RulesLintLevel                     string
 	// PollInterval is passed to resource.StateChangeConf in common_operation.go
 	// It controls the interval at which we poll for successful operations
 	PollInterval time.Duration
@@ -129,9 +131,14 @@ type Config struct {
 	userAgent          string
 	gRPCLoggingOptions []option.ClientOption
 
+	// firebaseDatabaseClient is client with FirebaseDatabaseScope added
+	firebaseDatabaseClient *http.Client
+
 	tokenSource oauth2.TokenSource
 
 	FirebaseRulesBasePath    string
+	FirebaseBasePath         string
+	FirebaseDatabaseBasePath string
 	IdentityPlatformBasePath string
 	MobileSDKBasePath        string
 	ComputeBasePath          string
@@ -141,12 +148,16 @@ type Config struct {
 }
 
 const FirebaseRulesBasePathKey = "FirebaseRules"
+const FirebaseBasePathKey = "Firebase"
+const FirebaseDatabaseBasePathKey = "FirebaseDatabase"
 const IdentityPlatformBasePathKey = "IdentityPlatform"
 const MobileSDKBasePathKey = "MobileSDK"
 
 // Generated product base paths
 var DefaultBasePaths = map[string]string{
 	FirebaseRulesBasePathKey:    "https://firebaserules.googleapis.com/v1/",
+	FirebaseBasePathKey:         "https://firebase.googleapis.com/v1beta1/",
+	FirebaseDatabaseBasePathKey: "https://{{instance}}.firebaseio.com/",
 	IdentityPlatformBasePathKey: "https://identitytoolkit.googleapis.com/admin/v2/",
 	MobileSDKBasePathKey:        "https://mobilesdk-pa.googleapis.com/v1/",
 }
@@ -156,6 +167,11 @@ var DefaultClientScopes = []string{
 	"https://www.googleapis.com/auth/userinfo.email",
 }
 
+// FirebaseDatabaseScope is needed by the Realtime Database REST API. It is only
+// requested for the client of sidkik_firebase_database_rules, so credentials
+// that cannot be granted it keep working for all other resources.
+const FirebaseDatabaseScope = "https://www.googleapis.com/auth/firebase.database"
+
 func (c *Config) LoadAndValidate(ctx context.Context) error {
 	if len(c.Scopes) == 0 {
 		c.Scopes = DefaultClientScopes
@@ -184,35 +200,26 @@ func (c *Config) LoadAndValidate(ctx context.Context) error {
 		return err
 	}
 
-	// 2. Logging Transport - ensure we log HTTP requests to GCP APIs.
-	loggingTransport := logging.NewTransport("Google", client.Transport)
-
-	// 3. Retry Transport - retries common temporary errors
-	// Keep order for wrapping logging so we log each retried request as well.
-	// This value should be used if needed to create shallow copies with additional retry predicates.
-	// See ClientWithAdditionalRetries
-	retryTransport := NewTransportWithDefaultRetries(loggingTransport)
-
-	// 4. Header Transport - outer wrapper to inject additional headers we want to apply
-	// before making requests
-	headerTransport := newTransportWithHeaders(retryTransport)
-	if c.RequestReason != "" {
-		headerTransport.Set("X-Goog-Request-Reason", c.RequestReason)
-	}
-
-	// Ensure $userProject is set for all HTTP requests using the client if specified by the provider config
-	// See https://cloud.google.com/apis/docs/system-parameters
-	if c.UserProjectOverride && c.BillingProject != "" {
-		headerTransport.Set("X-Goog-User-Project", c.BillingProject)
-	}
-
 	// Set final transport value.
-	client.Transport = headerTransport
+	client.Transport = c.wrapTransport(client.Transport)
 
 	// This timeout is a timeout per HTTP request, not per logical operation.
 	client.Timeout = c.synchronousTimeout()
 
+	// The Realtime Database client differs only in its token's scopes.
+	databaseTokenSource, err := c.getTokenSource(firebaseDatabaseScopes(c.Scopes), false)
+	if err != nil {
+		return err
+	}
+	databaseClient, _, err := transport.NewHTTPClient(cleanCtx, option.WithTokenSource(databaseTokenSource))
+	if err != nil {
+		return err
+	}
+	databaseClient.Transport = c.wrapTransport(databaseClient.Transport)
+	databaseClient.Timeout = c.synchronousTimeout()
+
 	c.client = client
+	c.firebaseDatabaseClient = databaseClient
 	c.context = ctx
 	c.Region = GetRegionFromRegionSelfLink(c.Region)
 	c.requestBatcherServiceUsage = NewRequestBatcher("Service Usage", ctx, c.BatchingConfig)
@@ -241,6 +248,44 @@ func (c *Config) LoadAndValidate(ctx context.Context) error {
 	return nil
 }
 
+// wrapTransport adds request logging, retries and the configured headers to
+// the authenticated transport of a client.
+func (c *Config) wrapTransport(authTransport http.RoundTripper) http.RoundTripper {
+	// 2. Logging Transport - ensure we log HTTP requests to GCP APIs.
+	loggingTransport := logging.NewTransport("Google", authTransport)
+
+	// 3. Retry Transport - retries common temporary errors
+	// Keep order for wrapping logging so we log each retried request as well.
+	// This value should be used if needed to create shallow copies with additional retry predicates.
+	// See ClientWithAdditionalRetries
+	retryTransport := NewTransportWithDefaultRetries(loggingTransport)
+
+	// 4. Header Transport - outer wrapper to inject additional headers we want to apply
+	// before making requests
+	headerTransport := newTransportWithHeaders(retryTransport)
+	if c.RequestReason != "" {
+		headerTransport.Set("X-Goog-Request-Reason", c.RequestReason)
+	}
+
+	// Ensure $userProject is set for all HTTP requests using the client if specified by the provider config
+	// See https://cloud.google.com/apis/docs/system-parameters
+	if c.UserProjectOverride && c.BillingProject != "" {
+		headerTransport.Set("X-Goog-User-Project", c.BillingProject)
+	}
+
+	return headerTransport
+}
+
+// firebaseDatabaseScopes returns scopes with FirebaseDatabaseScope added.
+func firebaseDatabaseScopes(scopes []string) []string {
+	for _, scope := range scopes {
+		if scope == FirebaseDatabaseScope {
+			return scopes
+		}
+	}
+	return append(append([]string{}, scopes...), FirebaseDatabaseScope)
+}
+
 func expandProviderBatchingConfig(v interface{}) (*batchingConfig, error) {
 	config := &batchingConfig{
 		sendAfter:      time.Second * defaultBatchSendIntervalSec,
@@ -444,6 +489,8 @@ func removeBasePathVersion(url string) string {
 // values to a default. After using this, you should call config.LoadAndValidate.
 func ConfigureBasePaths(c *Config) {
 	c.FirebaseRulesBasePath = DefaultBasePaths[FirebaseRulesBasePathKey]
+	c.FirebaseBasePath = DefaultBasePaths[FirebaseBasePathKey]
+	c.FirebaseDatabaseBasePath = DefaultBasePaths[FirebaseDatabaseBasePathKey]
 	c.MobileSDKBasePath = DefaultBasePaths[MobileSDKBasePathKey]
 	c.IdentityPlatformBasePath = DefaultBasePaths[IdentityPlatformBasePathKey]
 }
@@ -9,8 +9,16 @@ func dataSourceFirebaseFirestoreRule() *schema.Resource {
 	// Generate datasource schema from resource
 	dsSchema := datasourceSchemaFromResourceSchema(resourceFirebaseFirestoreRule().Schema)
 
+	// these only apply to releases made by the resource
+	delete(dsSchema, "rule_file")
+	delete(dsSchema, "test_case")
+	delete(dsSchema, "retain_rulesets")
+	delete(dsSchema, "on_destroy")
+	delete(dsSchema, "previous_ruleset_name")
+	delete(dsSchema, "created_release")
+
 	// Set 'Optional' schema elements
-	addOptionalFieldsToSchema(dsSchema, "project")
+	addOptionalFieldsToSchema(dsSchema, "project", "database")
 
 	return &schema.Resource{
 		Read:   dataSourceFirebaseFirestoreRuleRead,
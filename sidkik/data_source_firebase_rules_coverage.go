@@ -0,0 +1,164 @@
+package sidkik
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceFirebaseRulesCoverage() *schema.Resource {
+	testCase := firebaseRulesTestCaseSchema()
+	testCase.Optional = false
+	testCase.Required = true
+	testCase.MinItems = 1
+	testCase.Description = `Test cases run against the rule source to measure coverage`
+
+	return &schema.Resource{
+		Read: dataSourceFirebaseRulesCoverageRead,
+
+		Schema: map[string]*schema.Schema{
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"rule": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"rule", "rule_file", "files"},
+				ValidateFunc: validateFirebaseRulesSource,
+				Description:  `Rule source to test, e.g. the rule of a sidkik_firebase_firestore_rule`,
+			},
+			"rule_file": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"rule", "rule_file", "files"},
+				Description:  `Path to a file holding the rule source to test, as an alternative to rule`,
+			},
+			"files": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				ExactlyOneOf: []string{"rule", "rule_file", "files"},
+				Description:  `Source files to test, as an alternative to rule`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: `Name of the source file`,
+						},
+						"content": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateFirebaseRulesSource,
+							Description:  `Content of the source file`,
+						},
+					},
+				},
+			},
+			"test_case": testCase,
+			"minimum_coverage": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				ValidateFunc: validation.FloatBetween(0, 100),
+				Description:  `Percentage of allow conditions the test cases must exercise. Reading the data source fails below it`,
+			},
+			"coverage": {
+				Type:        schema.TypeFloat,
+				Computed:    true,
+				Description: `Percentage of allow conditions exercised by the test cases`,
+			},
+			"allow_condition_count": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: `Number of allow statements with a condition in the rule source`,
+			},
+			"covered_allow_condition_count": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: `Number of allow conditions evaluated by at least one test case`,
+			},
+			"uncovered_allow_conditions": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: `Positions of the allow conditions no test case evaluated, as file:line:column`,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+func dataSourceFirebaseRulesCoverageRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	}
+
+	files, err := firebaseRulesSourceFiles(d.Get("rule"), d.Get("rule_file"), d.Get("files"), "rules")
+	if err != nil {
+		return err
+	}
+
+	conditions, err := firebaseRulesAllowConditions(files)
+	if err != nil {
+		return err
+	}
+
+	testCases := d.Get("test_case").([]interface{})
+	testSuite, err := expandFirebaseRulesTestSuite(testCases)
+	if err != nil {
+		return err
+	}
+	for _, testCase := range testSuite["testCases"].([]interface{}) {
+		testCase.(map[string]interface{})["expressionReportLevel"] = "VISITED"
+	}
+
+	source := map[string]interface{}{
+		"files": files,
+	}
+	results, err := testFirebaseRulesCases(config, project, userAgent, source, testCases, testSuite)
+	if err != nil {
+		return err
+	}
+
+	coverage, covered, uncovered := firebaseRulesCoverage(conditions, flattenFirebaseRulesVisitedPositions(results))
+
+	uncoveredPositions := make([]string, 0, len(uncovered))
+	for _, condition := range uncovered {
+		uncoveredPositions = append(uncoveredPositions, condition.String())
+	}
+
+	if minimum, ok := d.GetOk("minimum_coverage"); ok && coverage < minimum.(float64) {
+		return fmt.Errorf("Firebase Rules coverage of %.2f%% is below minimum_coverage of %.2f%%. Allow conditions not evaluated by any test case:\n%s", coverage, minimum.(float64), strings.Join(uncoveredPositions, "\n"))
+	}
+
+	if err := d.Set("project", project); err != nil {
+		return fmt.Errorf("Error reading Firebase Rules coverage: %s", err)
+	}
+	if err := d.Set("coverage", coverage); err != nil {
+		return fmt.Errorf("Error reading Firebase Rules coverage: %s", err)
+	}
+	if err := d.Set("allow_condition_count", len(conditions)); err != nil {
+		return fmt.Errorf("Error reading Firebase Rules coverage: %s", err)
+	}
+	if err := d.Set("covered_allow_condition_count", len(covered)); err != nil {
+		return fmt.Errorf("Error reading Firebase Rules coverage: %s", err)
+	}
+	if err := d.Set("uncovered_allow_conditions", uncoveredPositions); err != nil {
+		return fmt.Errorf("Error reading Firebase Rules coverage: %s", err)
+	}
+
+	d.SetId(fmt.Sprintf("projects/%s/coverage/%s", project, firebaseRulesSourceSha256(files)))
+
+	return nil
+}
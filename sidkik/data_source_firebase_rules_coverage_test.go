@@ -0,0 +1,80 @@
+package sidkik
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccFirebaseRulesCoverageDatasource_basic(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{
+		"minimum_coverage": 50,
+	}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseRulesCoverageDatasource_basic(context),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.sidkik_firebase_rules_coverage.coverage", "coverage", "50"),
+					resource.TestCheckResourceAttr("data.sidkik_firebase_rules_coverage.coverage", "allow_condition_count", "2"),
+					resource.TestCheckResourceAttr("data.sidkik_firebase_rules_coverage.coverage", "covered_allow_condition_count", "1"),
+					resource.TestCheckResourceAttr("data.sidkik_firebase_rules_coverage.coverage", "uncovered_allow_conditions.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFirebaseRulesCoverageDatasource_belowMinimum(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{
+		"minimum_coverage": 100,
+	}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccFirebaseRulesCoverageDatasource_basic(context),
+				ExpectError: regexp.MustCompile("below minimum_coverage"),
+			},
+		},
+	})
+}
+
+func testAccFirebaseRulesCoverageDatasource_basic(context map[string]interface{}) string {
+	return Nprintf(`
+data "sidkik_firebase_rules_coverage" "coverage" {
+	rule = <<EOT
+rules_version = '2';
+service cloud.firestore {
+	match /databases/{database}/documents {
+		match /users/{userId} {
+			allow read: if request.auth != null;
+			allow write: if request.auth.uid == userId;
+		}
+	}
+}
+EOT
+
+	test_case {
+		name        = "anonymous cannot read"
+		expectation = "DENY"
+		request = jsonencode({
+			method = "get"
+			path   = "/databases/(default)/documents/users/alice"
+		})
+	}
+
+	minimum_coverage = %{minimum_coverage}
+}
+`, context)
+}
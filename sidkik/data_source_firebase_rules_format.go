@@ -0,0 +1,83 @@
+package sidkik
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceFirebaseRulesFormat() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceFirebaseRulesFormatRead,
+
+		Schema: map[string]*schema.Schema{
+			"rule": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"rule", "rule_file"},
+				ValidateFunc: validateFirebaseRulesSource,
+				Description:  `Rule source to format`,
+			},
+			"rule_file": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"rule", "rule_file"},
+				Description:  `Path to a file holding the rule source to format, as an alternative to rule`,
+			},
+			"require_formatted": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: `Fail reading the data source when the rule source is not in canonical format`,
+			},
+			"formatted": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `The rule source in canonical format`,
+			},
+			"is_formatted": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: `Whether the rule source is already in canonical format`,
+			},
+		},
+	}
+}
+
+func dataSourceFirebaseRulesFormatRead(d *schema.ResourceData, meta interface{}) error {
+	files, err := firebaseRulesSourceFiles(d.Get("rule"), d.Get("rule_file"), nil, "rules")
+	if err != nil {
+		return err
+	}
+	src := ""
+	if len(files) > 0 {
+		src = files[0].(map[string]interface{})["content"].(string)
+	}
+
+	formatted, err := formatFirebaseRules(src)
+	if err != nil {
+		return fmt.Errorf("Error formatting Firebase Rules: %s", err)
+	}
+
+	isFormatted := formatted == src
+	if d.Get("require_formatted").(bool) && !isFormatted {
+		if path := d.Get("rule_file").(string); path != "" {
+			return fmt.Errorf("Firebase Rules in rule_file %q are not in canonical format", path)
+		}
+		return fmt.Errorf("Firebase Rules are not in canonical format")
+	}
+
+	if err := d.Set("formatted", formatted); err != nil {
+		return fmt.Errorf("Error reading Firebase Rules format: %s", err)
+	}
+	if err := d.Set("is_formatted", isFormatted); err != nil {
+		return fmt.Errorf("Error reading Firebase Rules format: %s", err)
+	}
+
+	sum := sha256.Sum256([]byte(formatted))
+	d.SetId(hex.EncodeToString(sum[:]))
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package sidkik
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccFirebaseRulesFormatDatasource_basic(t *testing.T) {
+	t.Parallel()
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseRulesFormatDatasource_basic(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.sidkik_firebase_rules_format.unformatted", "is_formatted", "false"),
+					resource.TestCheckResourceAttr("data.sidkik_firebase_rules_format.unformatted", "formatted", "rules_version = '2';\nservice cloud.firestore {\n  match /databases/{database}/documents {\n    match /users/{userId} {\n      allow read: if request.auth != null;\n    }\n  }\n}\n"),
+					resource.TestCheckResourceAttr("data.sidkik_firebase_rules_format.formatted", "is_formatted", "true"),
+					resource.TestCheckResourceAttrPair("data.sidkik_firebase_rules_format.formatted", "formatted", "data.sidkik_firebase_rules_format.unformatted", "formatted"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccFirebaseRulesFormatDatasource_requireFormatted(t *testing.T) {
+	t.Parallel()
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccFirebaseRulesFormatDatasource_requireFormatted(),
+				ExpectError: regexp.MustCompile("not in canonical format"),
+			},
+		},
+	})
+}
+
+func testAccFirebaseRulesFormatDatasource_basic() string {
+	return `
+data "sidkik_firebase_rules_format" "unformatted" {
+	rule = "rules_version='2';\nservice cloud.firestore{\nmatch /databases/{database}/documents {\n    match /users/{userId} { allow read: if request.auth!=null; }\n}\n}"
+}
+
+data "sidkik_firebase_rules_format" "formatted" {
+	rule              = data.sidkik_firebase_rules_format.unformatted.formatted
+	require_formatted = true
+}
+`
+}
+
+func testAccFirebaseRulesFormatDatasource_requireFormatted() string {
+	return `
+data "sidkik_firebase_rules_format" "unformatted" {
+	rule              = "rules_version='2';\nservice cloud.firestore{\nmatch /databases/{database}/documents {\n    match /users/{userId} { allow read: if request.auth!=null; }\n}\n}"
+	require_formatted = true
+}
+`
+}
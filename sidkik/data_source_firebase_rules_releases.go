@@ -0,0 +1,121 @@
+package sidkik
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceFirebaseRulesReleases() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceFirebaseRulesReleasesRead,
+
+		Schema: map[string]*schema.Schema{
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"name_prefix": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: `Only return releases whose name within the project starts with this prefix, e.g. cloud.firestore`,
+			},
+			"created_after": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateRFC3339Date,
+				Description:  `Only return releases created at or after this RFC3339 timestamp`,
+			},
+			"created_before": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateRFC3339Date,
+				Description:  `Only return releases created before this RFC3339 timestamp`,
+			},
+			"releases": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: `Matching releases, newest first`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: `Name of the release within the project, e.g. cloud.firestore`,
+						},
+						"ruleset_name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: `Name of the ruleset the release points at`,
+						},
+						"create_time": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: `Time the release was created`,
+						},
+						"update_time": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: `Time the release was last updated`,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceFirebaseRulesReleasesRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	}
+
+	releases, err := listFirebaseRules(d, config, userAgent, "releases")
+	if err != nil {
+		return fmt.Errorf("Error listing Firebase Releases: %s", err)
+	}
+
+	releases = filterFirebaseRulesByCreateTime(releases, d.Get("created_after").(string), d.Get("created_before").(string))
+
+	if err := d.Set("project", project); err != nil {
+		return fmt.Errorf("Error reading Firebase Releases: %s", err)
+	}
+	if err := d.Set("releases", flattenFirebaseRulesReleases(releases, project, d.Get("name_prefix").(string))); err != nil {
+		return fmt.Errorf("Error reading Firebase Releases: %s", err)
+	}
+
+	d.SetId(fmt.Sprintf("projects/%s/releases", project))
+
+	return nil
+}
+
+// flattenFirebaseRulesReleases flattens the releases whose name within the
+// project starts with namePrefix.
+func flattenFirebaseRulesReleases(releases []interface{}, project, namePrefix string) []interface{} {
+	prefix := fmt.Sprintf("projects/%s/releases/", project)
+
+	transformed := make([]interface{}, 0, len(releases))
+	for _, raw := range releases {
+		release := raw.(map[string]interface{})
+		name := strings.TrimPrefix(release["name"].(string), prefix)
+		if !strings.HasPrefix(name, namePrefix) {
+			continue
+		}
+		transformed = append(transformed, map[string]interface{}{
+			"name":         name,
+			"ruleset_name": release["rulesetName"],
+			"create_time":  release["createTime"],
+			"update_time":  release["updateTime"],
+		})
+	}
+	return transformed
+}
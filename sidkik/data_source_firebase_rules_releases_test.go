@@ -0,0 +1,67 @@
+package sidkik
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccFirebaseRulesReleasesDatasource_namePrefix(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseRulesReleasesDatasource_namePrefix(context),
+				Check:  resource.TestCheckResourceAttr("data.sidkik_firebase_rules_releases.firestore", "releases.0.name", "cloud.firestore"),
+			},
+		},
+	})
+}
+
+func testAccFirebaseRulesReleasesDatasource_namePrefix(context map[string]interface{}) string {
+	return Nprintf(`
+data "sidkik_firebase_rules_releases" "firestore" {
+	name_prefix   = "cloud.firestore"
+	created_after = "2000-01-01T00:00:00Z"
+}
+`, context)
+}
+
+func Test_flattenFirebaseRulesReleases(t *testing.T) {
+	releases := []interface{}{
+		map[string]interface{}{
+			"name":        "projects/my-project/releases/cloud.firestore",
+			"rulesetName": "projects/my-project/rulesets/firestore-1",
+			"createTime":  "2021-12-10T00:00:00Z",
+			"updateTime":  "2021-12-11T00:00:00Z",
+		},
+		map[string]interface{}{
+			"name":        "projects/my-project/releases/firebase.storage/my-project.appspot.com",
+			"rulesetName": "projects/my-project/rulesets/storage-1",
+			"createTime":  "2021-12-09T00:00:00Z",
+			"updateTime":  "2021-12-09T00:00:00Z",
+		},
+	}
+
+	want := []interface{}{
+		map[string]interface{}{
+			"name":         "firebase.storage/my-project.appspot.com",
+			"ruleset_name": "projects/my-project/rulesets/storage-1",
+			"create_time":  "2021-12-09T00:00:00Z",
+			"update_time":  "2021-12-09T00:00:00Z",
+		},
+	}
+
+	if got := flattenFirebaseRulesReleases(releases, "my-project", "firebase.storage"); !reflect.DeepEqual(got, want) {
+		t.Errorf("flattenFirebaseRulesReleases() = %#v, want %#v", got, want)
+	}
+	if got := flattenFirebaseRulesReleases(releases, "my-project", ""); len(got) != 2 {
+		t.Errorf("flattenFirebaseRulesReleases() returned %d releases, want 2", len(got))
+	}
+}
@@ -0,0 +1,172 @@
+package sidkik
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceFirebaseRulesets() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceFirebaseRulesetsRead,
+
+		Schema: map[string]*schema.Schema{
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"service": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: `Only return rulesets with declarations for this service, e.g. cloud.firestore or firebase.storage`,
+			},
+			"created_after": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateRFC3339Date,
+				Description:  `Only return rulesets created at or after this RFC3339 timestamp`,
+			},
+			"created_before": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateRFC3339Date,
+				Description:  `Only return rulesets created before this RFC3339 timestamp`,
+			},
+			"limit": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  `Only return the newest limit matching rulesets`,
+			},
+			"include_files": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: `Read the source files of each returned ruleset. This takes one request per ruleset, so combine it with limit or the other filters in projects with many rulesets`,
+			},
+			"rulesets": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: `Matching rulesets, newest first`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: `name of the ruleset, e.g. projects/{{project}}/rulesets/{{ruleset_id}}`,
+						},
+						"create_time": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: `Time the ruleset was created`,
+						},
+						"services": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: `Services the ruleset has declarations for`,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"files": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: `Source files of the ruleset. Only read when include_files is set`,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"name": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: `Name of the source file`,
+									},
+									"content": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: `Content of the source file`,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceFirebaseRulesetsRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	}
+
+	rulesets, err := listFirebaseRules(d, config, userAgent, "rulesets")
+	if err != nil {
+		return fmt.Errorf("Error listing Firebase Rulesets: %s", err)
+	}
+
+	rulesets = filterFirebaseRulesets(rulesets, d.Get("service").(string), d.Get("created_after").(string), d.Get("created_before").(string), d.Get("limit").(int))
+
+	includeFiles := d.Get("include_files").(bool)
+	transformed := make([]interface{}, 0, len(rulesets))
+	for _, raw := range rulesets {
+		ruleset := raw.(map[string]interface{})
+
+		files := make([]interface{}, 0)
+		if includeFiles {
+			// the list only returns metadata, so the source is fetched per ruleset
+			url, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}"+ruleset["name"].(string))
+			if err != nil {
+				return err
+			}
+			res, err := sendRequest(config, "GET", project, url, userAgent, nil)
+			if err != nil {
+				return fmt.Errorf("Error reading Firebase Ruleset %q: %s", ruleset["name"], err)
+			}
+			files = flattenFirebaseRulesFiles(res["source"], d, config)
+		}
+
+		transformed = append(transformed, map[string]interface{}{
+			"name":        ruleset["name"],
+			"create_time": ruleset["createTime"],
+			"services":    flattenFirebaseRulesetServices(ruleset["metadata"], d, config),
+			"files":       files,
+		})
+	}
+
+	if err := d.Set("project", project); err != nil {
+		return fmt.Errorf("Error reading Firebase Rulesets: %s", err)
+	}
+	if err := d.Set("rulesets", transformed); err != nil {
+		return fmt.Errorf("Error reading Firebase Rulesets: %s", err)
+	}
+
+	d.SetId(fmt.Sprintf("projects/%s/rulesets", project))
+
+	return nil
+}
+
+// filterFirebaseRulesets returns the rulesets with declarations for service,
+// when set, created within [after, before), newest first and at most limit of
+// them when limit is set.
+func filterFirebaseRulesets(rulesets []interface{}, service, after, before string, limit int) []interface{} {
+	filtered := make([]interface{}, 0)
+	for _, raw := range filterFirebaseRulesByCreateTime(rulesets, after, before) {
+		if limit > 0 && len(filtered) >= limit {
+			break
+		}
+		if service != "" && !firebaseRulesetHasService(raw.(map[string]interface{}), service) {
+			continue
+		}
+		filtered = append(filtered, raw)
+	}
+	return filtered
+}
@@ -0,0 +1,103 @@
+package sidkik
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccFirebaseRulesetsDatasource_service(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseRulesetsDatasource_service(context),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.sidkik_firebase_rulesets.firestore", "rulesets.0.services.0", "cloud.firestore"),
+					resource.TestCheckResourceAttrSet("data.sidkik_firebase_rulesets.firestore", "rulesets.0.files.0.content"),
+				),
+			},
+		},
+	})
+}
+
+func testAccFirebaseRulesetsDatasource_service(context map[string]interface{}) string {
+	return Nprintf(`
+resource "sidkik_firebase_ruleset" "ruleset" {
+	files {
+		name    = "firestore.rules"
+		content = <<EOT
+rules_version = '2';
+service cloud.firestore {
+	match /databases/{database}/documents {
+		match /{document=**} {
+			allow read, write: if false;
+		}
+	}
+}
+EOT
+	}
+}
+
+data "sidkik_firebase_rulesets" "firestore" {
+	service       = "cloud.firestore"
+	created_after = sidkik_firebase_ruleset.ruleset.create_time
+	limit         = 1
+	include_files = true
+}
+`, context)
+}
+
+func Test_filterFirebaseRulesets(t *testing.T) {
+	var rulesets []interface{}
+	if err := json.Unmarshal([]byte(`[
+		{"name": "projects/my-project/rulesets/a", "createTime": "2021-12-10T00:00:00Z", "metadata": {"services": ["cloud.firestore"]}},
+		{"name": "projects/my-project/rulesets/b", "createTime": "2021-12-11T00:00:00Z", "metadata": {"services": ["firebase.storage"]}},
+		{"name": "projects/my-project/rulesets/c", "createTime": "2021-12-12T00:00:00Z", "metadata": {"services": ["cloud.firestore"]}},
+		{"name": "projects/my-project/rulesets/d", "createTime": "2021-12-13T00:00:00Z", "metadata": {"services": ["cloud.firestore"]}}
+	]`), &rulesets); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		service  string
+		before   string
+		limit    int
+		expected []string
+	}{
+		"all": {
+			expected: []string{"d", "c", "b", "a"},
+		},
+		"service": {
+			service:  "cloud.firestore",
+			expected: []string{"d", "c", "a"},
+		},
+		"limit": {
+			limit:    2,
+			expected: []string{"d", "c"},
+		},
+		"limit applies after filters": {
+			service:  "cloud.firestore",
+			before:   "2021-12-13T00:00:00Z",
+			limit:    2,
+			expected: []string{"c", "a"},
+		},
+	}
+
+	for tn, tc := range cases {
+		got := []string{}
+		for _, raw := range filterFirebaseRulesets(rulesets, tc.service, "", tc.before, tc.limit) {
+			got = append(got, raw.(map[string]interface{})["name"].(string)[len("projects/my-project/rulesets/"):])
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("bad: %s, expected %v, got %v", tn, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package sidkik
+
+import (
+	"fmt"
+	"strings"
+)
+
+// firebaseRulesIndent is the indentation of one block level in formatted
+// rule source.
+const firebaseRulesIndent = "  "
+
+// firebaseRulesStatementKeywords start a statement. They end the previous
+// statement when its semicolon is omitted.
+var firebaseRulesStatementKeywords = []string{"rules_version", "service", "match", "allow", "function", "let", "return"}
+
+// firebaseRulesExprKeywords are identifiers that are followed by an operand.
+var firebaseRulesExprKeywords = []string{"if", "return", "in", "is"}
+
+// formatFirebaseRules returns src in canonical format. Every statement starts
+// on its own line, indented by its block depth, and is joined onto that line
+// with normalized spacing, so the output does not depend on how src wraps
+// statements. Comments and single blank lines between statements are kept; a
+// comment within a statement continues it on the next line.
+func formatFirebaseRules(src string) (string, error) {
+	if _, err := parseFirebaseRules(src); err != nil {
+		return "", err
+	}
+
+	tokens, err := lexFirebaseRules(src)
+	if err != nil {
+		return "", err
+	}
+
+	f := &firebaseRulesFormatter{
+		tokens: tokens,
+		glue:   make(map[int]bool),
+		path:   make(map[int]bool),
+	}
+	f.markExpr(firebaseRulesSignificantTokens(tokens), 0, "")
+	formatted := f.format()
+
+	// formatting only ever changes whitespace
+	if !firebaseRulesSourceEquivalent(src, formatted) {
+		return "", fmt.Errorf("formatting changed the tokens of the rules")
+	}
+	return formatted, nil
+}
+
+type firebaseRulesFormatFrame struct {
+	// kind is the opening token of the frame, with "block" for the braces of
+	// a service, match or function
+	kind    string
+	ternary int
+}
+
+type firebaseRulesFormatter struct {
+	tokens []firebaseRulesToken
+	// glue holds the offsets of tokens printed without a space before them
+	glue map[int]bool
+	// path holds the offsets of the tokens of literal path segments and
+	// wildcards, which are printed as is
+	path map[int]bool
+
+	lines      []string
+	line       strings.Builder
+	lineIndent int
+	depth      int
+	stack      []*firebaseRulesFormatFrame
+
+	// prev is the last significant token written and prevEnd the line the
+	// last token, including comments, ended on in the source
+	prev           *firebaseRulesToken
+	prevEnd        int
+	prevUnary      bool
+	prevMapOpen    bool
+	inStatement    bool
+	afterBlockOpen bool
+	breakLine      bool
+}
+
+// markExpr marks the paths and string prefixes in tokens from i up to the
+// closing token at the same nesting level, and returns the index of the
+// closing token.
+func (f *firebaseRulesFormatter) markExpr(tokens []firebaseRulesToken, i int, closing string) int {
+	depth := 0
+	var prev *firebaseRulesToken
+	for i < len(tokens) {
+		tok := &tokens[i]
+		if depth == 0 && closing != "" && tok.text == closing {
+			return i
+		}
+
+		if tok.text == "/" && tok.kind == firebaseRulesTokenPunct {
+			if prev != nil && prev.kind == firebaseRulesTokenIdent && prev.text == "match" {
+				i = f.markPath(tokens, i, false)
+				prev = &tokens[i-1]
+				continue
+			}
+			if isFirebaseRulesOperandPosition(prev) {
+				i = f.markPath(tokens, i, true)
+				prev = &tokens[i-1]
+				continue
+			}
+		}
+
+		if tok.kind == firebaseRulesTokenString && prev != nil && prev.kind == firebaseRulesTokenIdent && isFirebaseRulesStringPrefix(prev.text) && isFirebaseRulesAdjacent(tokens, i) {
+			f.glue[tok.offset] = true
+		}
+
+		switch tok.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		}
+		prev = tok
+		i++
+	}
+	return i
+}
+
+// markPath marks the tokens of the path starting with the "/" at tokens[i],
+// mirroring parsePath, and returns the index of the first token after it.
+func (f *firebaseRulesFormatter) markPath(tokens []firebaseRulesToken, i int, inExpr bool) int {
+	f.path[tokens[i].offset] = true
+	i++
+	for i < len(tokens) && isFirebaseRulesAdjacent(tokens, i) {
+		switch {
+		case tokens[i].text == "{" && !inExpr:
+			for i < len(tokens) {
+				f.glue[tokens[i].offset] = true
+				f.path[tokens[i].offset] = true
+				i++
+				if tokens[i-1].text == "}" {
+					break
+				}
+			}
+		case tokens[i].text == "$" && inExpr:
+			f.glue[tokens[i].offset] = true
+			f.path[tokens[i].offset] = true
+			f.glue[tokens[i+1].offset] = true
+			i = f.markExpr(tokens, i+2, ")")
+			if i < len(tokens) {
+				f.glue[tokens[i].offset] = true
+				i++
+			}
+		default:
+			depth := 0
+			for i < len(tokens) && isFirebaseRulesAdjacent(tokens, i) && (isFirebaseRulesPathToken(&tokens[i]) || tokens[i].text == "(" || (tokens[i].text == ")" && depth > 0)) {
+				switch tokens[i].text {
+				case "(":
+					depth++
+				case ")":
+					depth--
+				}
+				f.glue[tokens[i].offset] = true
+				f.path[tokens[i].offset] = true
+				i++
+			}
+		}
+
+		if i >= len(tokens) || !isFirebaseRulesAdjacent(tokens, i) || tokens[i].text != "/" {
+			return i
+		}
+		f.glue[tokens[i].offset] = true
+		f.path[tokens[i].offset] = true
+		i++
+	}
+	return i
+}
+
+func (f *firebaseRulesFormatter) format() string {
+	for i := range f.tokens {
+		tok := &f.tokens[i]
+		if tok.kind == firebaseRulesTokenComment {
+			f.comment(tok, f.next(i))
+		} else {
+			f.token(tok)
+		}
+		f.prevEnd = tok.line + strings.Count(tok.text, "\n")
+	}
+	f.newline()
+
+	if len(f.lines) == 0 {
+		return ""
+	}
+	return strings.Join(f.lines, "\n") + "\n"
+}
+
+// next returns the first significant token after tokens[i], or nil.
+func (f *firebaseRulesFormatter) next(i int) *firebaseRulesToken {
+	for i++; i < len(f.tokens); i++ {
+		if f.tokens[i].kind != firebaseRulesTokenComment {
+			return &f.tokens[i]
+		}
+	}
+	return nil
+}
+
+// comment writes a comment, trailing the current line if it started on it in
+// the source. A comment on its own line before the next statement is indented
+// with that statement, even if the previous one omits its semicolon.
+func (f *firebaseRulesFormatter) comment(tok, next *firebaseRulesToken) {
+	if tok.line == f.prevEnd && f.line.Len() > 0 {
+		f.write(true, tok.text)
+	} else {
+		top := f.top()
+		if f.inStatement && (top == nil || top.kind == "block") && (next == nil || next.text == "}" || (next.kind == firebaseRulesTokenIdent && stringInSlice(firebaseRulesStatementKeywords, next.text))) {
+			f.inStatement = false
+		}
+		f.newline()
+		if tok.line-f.prevEnd >= 2 && !f.afterBlockOpen {
+			f.blankLine()
+		}
+		f.write(false, tok.text)
+		f.breakLine = true
+		f.afterBlockOpen = false
+	}
+
+	if strings.HasPrefix(tok.text, "//") {
+		f.breakLine = true
+	}
+}
+
+func (f *firebaseRulesFormatter) token(tok *firebaseRulesToken) {
+	top := f.top()
+	inPath := f.path[tok.offset]
+	blockLevel := top == nil || top.kind == "block"
+
+	// a statement keyword ends a statement without a semicolon
+	if f.inStatement && blockLevel && !inPath && tok.kind == firebaseRulesTokenIdent && stringInSlice(firebaseRulesStatementKeywords, tok.text) && f.prev.text != "." {
+		f.inStatement = false
+	}
+
+	closesBlock := tok.text == "}" && !inPath && blockLevel && top != nil
+	opensBlock := tok.text == "{" && !inPath && isFirebaseRulesOperandEnd(f.prev)
+	ternaryColon := tok.text == ":" && top != nil && top.ternary > 0
+	unary := (tok.text == "-" || tok.text == "!") && isFirebaseRulesOperandPosition(f.prev)
+
+	if closesBlock {
+		f.stack = f.stack[:len(f.stack)-1]
+		f.depth--
+		f.inStatement = false
+	}
+
+	switch {
+	case !f.inStatement:
+		f.newline()
+		if f.prev != nil && tok.line-f.prevEnd >= 2 && !f.afterBlockOpen && !closesBlock {
+			f.blankLine()
+		}
+		f.write(false, tok.text)
+		f.inStatement = !closesBlock
+	case f.breakLine:
+		f.newline()
+		f.write(false, tok.text)
+	default:
+		f.write(f.spaceBefore(tok, top, ternaryColon), tok.text)
+	}
+
+	f.breakLine = false
+	f.afterBlockOpen = false
+	f.prevMapOpen = false
+
+	switch {
+	case inPath || closesBlock:
+	case opensBlock:
+		f.stack = append(f.stack, &firebaseRulesFormatFrame{kind: "block"})
+		f.depth++
+		f.inStatement = false
+		f.afterBlockOpen = true
+	case tok.text == "{":
+		f.stack = append(f.stack, &firebaseRulesFormatFrame{kind: "{"})
+		f.prevMapOpen = true
+	case tok.text == "(" || tok.text == "[":
+		f.stack = append(f.stack, &firebaseRulesFormatFrame{kind: tok.text})
+	case tok.text == ")" || tok.text == "]" || tok.text == "}":
+		if top != nil {
+			f.stack = f.stack[:len(f.stack)-1]
+		}
+	case tok.text == "?" && top != nil:
+		top.ternary++
+	case ternaryColon:
+		top.ternary--
+	case tok.text == ";" && blockLevel:
+		f.inStatement = false
+	}
+
+	f.prev = tok
+	f.prevUnary = unary
+}
+
+// spaceBefore reports whether a space separates tok from the previous token
+// on the same line.
+func (f *firebaseRulesFormatter) spaceBefore(tok *firebaseRulesToken, top *firebaseRulesFormatFrame, ternaryColon bool) bool {
+	prev := f.prev
+	switch {
+	case f.glue[tok.offset]:
+		return false
+	case prev.text == "(" || prev.text == "[" || prev.text == "." || f.prevUnary || f.prevMapOpen:
+		return false
+	case tok.text == ")" || tok.text == "]" || tok.text == "." || tok.text == "," || tok.text == ";":
+		return false
+	case tok.text == "}" && top != nil && top.kind == "{":
+		return false
+	case tok.text == ":" && !ternaryColon:
+		return false
+	case prev.text == ":" && top != nil && top.kind == "[":
+		// slices, e.g. request.path[1:3]
+		return false
+	case (tok.text == "(" || tok.text == "[") && isFirebaseRulesOperandEnd(prev):
+		// calls and indexes
+		return false
+	}
+	return true
+}
+
+func (f *firebaseRulesFormatter) top() *firebaseRulesFormatFrame {
+	if len(f.stack) == 0 {
+		return nil
+	}
+	return f.stack[len(f.stack)-1]
+}
+
+// write appends text to the current line. The indentation of a line is set by
+// its first text: the block depth, plus one for the continuation of a
+// statement.
+func (f *firebaseRulesFormatter) write(space bool, text string) {
+	if f.line.Len() == 0 {
+		f.lineIndent = f.depth
+		if f.inStatement {
+			f.lineIndent++
+		}
+	} else if space {
+		f.line.WriteString(" ")
+	}
+	f.line.WriteString(text)
+}
+
+func (f *firebaseRulesFormatter) newline() {
+	if f.line.Len() == 0 {
+		return
+	}
+	f.lines = append(f.lines, strings.Repeat(firebaseRulesIndent, f.lineIndent)+f.line.String())
+	f.line.Reset()
+}
+
+func (f *firebaseRulesFormatter) blankLine() {
+	if len(f.lines) > 0 && f.lines[len(f.lines)-1] != "" {
+		f.lines = append(f.lines, "")
+	}
+}
+
+// isFirebaseRulesOperandEnd reports whether tok may end an operand, so that a
+// following "(" or "[" is a call or index and a "{" opens a block.
+func isFirebaseRulesOperandEnd(tok *firebaseRulesToken) bool {
+	if tok == nil {
+		return false
+	}
+	switch tok.kind {
+	case firebaseRulesTokenNumber, firebaseRulesTokenString:
+		return true
+	case firebaseRulesTokenIdent:
+		return !stringInSlice(firebaseRulesExprKeywords, tok.text)
+	}
+	return tok.text == ")" || tok.text == "]" || tok.text == "}"
+}
+
+// isFirebaseRulesOperandPosition reports whether an operand is expected after
+// tok, so that a "-" is unary and a "/" starts a path.
+func isFirebaseRulesOperandPosition(tok *firebaseRulesToken) bool {
+	return !isFirebaseRulesOperandEnd(tok)
+}
+
+// isFirebaseRulesAdjacent reports whether tokens[i] directly follows the
+// previous token.
+func isFirebaseRulesAdjacent(tokens []firebaseRulesToken, i int) bool {
+	if i == 0 || i >= len(tokens) {
+		return false
+	}
+	prev := tokens[i-1]
+	return tokens[i].offset == prev.offset+len(prev.text)
+}
@@ -0,0 +1,190 @@
+package sidkik
+
+import (
+	"testing"
+)
+
+func Test_formatFirebaseRules(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "firestore",
+			src: `rules_version='2';
+service cloud.firestore{
+match /databases/{database}/documents {
+    function isSignedIn(){return request.auth!=null;}
+
+
+    function isOwner( userId ) {
+        let uid=request.auth.uid;
+        return isSignedIn()&&uid==userId;
+    }
+
+    match /users/{ userId } {
+      allow read : if isOwner(userId)||get(/databases/$( database )/documents/admins/$(request.auth.uid)).data.admin==true; allow delete: if false;
+      allow create,update: if isOwner(userId)
+      && request.resource.data.keys().hasOnly([ 'name','age' ])
+      && request.resource.data.age is int&&request.resource.data.name.size() in [1,2,3];
+    }
+    match /{document=**} { allow read, write: if request.auth.token.roles[0]=='admin'?true:!(-1>0); }
+}
+}
+`,
+			want: `rules_version = '2';
+service cloud.firestore {
+  match /databases/{database}/documents {
+    function isSignedIn() {
+      return request.auth != null;
+    }
+
+    function isOwner(userId) {
+      let uid = request.auth.uid;
+      return isSignedIn() && uid == userId;
+    }
+
+    match /users/{userId} {
+      allow read: if isOwner(userId) || get(/databases/$(database)/documents/admins/$(request.auth.uid)).data.admin == true;
+      allow delete: if false;
+      allow create, update: if isOwner(userId) && request.resource.data.keys().hasOnly(['name', 'age']) && request.resource.data.age is int && request.resource.data.name.size() in [1, 2, 3];
+    }
+    match /{document=**} {
+      allow read, write: if request.auth.token.roles[0] == 'admin' ? true : !(-1 > 0);
+    }
+  }
+}
+`,
+		},
+		{
+			name: "storage without semicolons",
+			src: `rules_version = '2'
+service firebase.storage {
+  match /b/{bucket}/o {
+    match /images/{imageId} {
+      allow read
+      allow write: if request.resource.size < 5*1024*1024 && request.resource.contentType.matches(r'image/.*')
+        && {'a':1,'b':[2,]}.keys().size()==2 && request.path[1 : 3] != null
+    }
+    match /user-files/{allPaths=**} {
+      allow read: if exists(/databases/(default)/documents/users/$(request.auth.uid))
+    }
+  }
+}`,
+			want: `rules_version = '2'
+service firebase.storage {
+  match /b/{bucket}/o {
+    match /images/{imageId} {
+      allow read
+      allow write: if request.resource.size < 5 * 1024 * 1024 && request.resource.contentType.matches(r'image/.*') && {'a': 1, 'b': [2,]}.keys().size() == 2 && request.path[1:3] != null
+    }
+    match /user-files/{allPaths=**} {
+      allow read: if exists(/databases/(default)/documents/users/$(request.auth.uid))
+    }
+  }
+}
+`,
+		},
+		{
+			name: "comments",
+			src: `// shared helpers
+rules_version = '2'
+  // the service
+
+
+service cloud.firestore {   // firestore
+  match /databases/{database}/documents {
+  /* owners
+     only */
+    match /notes/{note} {
+      allow read: if request.auth != null  // signed in
+        && request.auth.uid == resource.data.owner;
+    }
+  }
+}
+`,
+			want: `// shared helpers
+rules_version = '2'
+// the service
+
+service cloud.firestore { // firestore
+  match /databases/{database}/documents {
+    /* owners
+     only */
+    match /notes/{note} {
+      allow read: if request.auth != null // signed in
+        && request.auth.uid == resource.data.owner;
+    }
+  }
+}
+`,
+		},
+		{
+			name: "empty",
+			src:  "\n\n",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatFirebaseRules(tt.src)
+			if err != nil {
+				t.Fatalf("formatFirebaseRules() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("formatFirebaseRules() = \n%s\nwant\n%s", got, tt.want)
+			}
+
+			again, err := formatFirebaseRules(got)
+			if err != nil {
+				t.Fatalf("formatFirebaseRules() of formatted source error = %v", err)
+			}
+			if again != got {
+				t.Errorf("formatFirebaseRules() of formatted source = \n%s\nwant\n%s", again, got)
+			}
+		})
+	}
+}
+
+func Test_formatFirebaseRulesLineBreaks(t *testing.T) {
+	sources := []string{
+		`service cloud.firestore {
+  match /databases/{database}/documents/users/{userId} {
+    allow update: if request.auth != null && request.auth.uid == userId
+      && request.resource.data.keys().hasOnly(['name', 'age']);
+  }
+}`,
+		`service cloud.firestore { match /databases/{database}/documents/users/{userId} {
+    allow update: if request.auth != null
+                  && request.auth.uid == userId
+                  && request.resource.data.keys().hasOnly([
+                    'name',
+                    'age'
+                  ]);
+} }`,
+	}
+	want := `service cloud.firestore {
+  match /databases/{database}/documents/users/{userId} {
+    allow update: if request.auth != null && request.auth.uid == userId && request.resource.data.keys().hasOnly(['name', 'age']);
+  }
+}
+`
+	for i, src := range sources {
+		got, err := formatFirebaseRules(src)
+		if err != nil {
+			t.Fatalf("formatFirebaseRules() of source %d error = %v", i, err)
+		}
+		if got != want {
+			t.Errorf("formatFirebaseRules() of source %d = \n%s\nwant\n%s", i, got, want)
+		}
+	}
+}
+
+func Test_formatFirebaseRulesErrors(t *testing.T) {
+	src := "service cloud.firestore {\n  match /a {\n    allow reed: if true;\n  }\n}"
+	want := `3:11: unknown method "reed", expected one of read, write, get, list, create, update, delete`
+	if _, err := formatFirebaseRules(src); err == nil || err.Error() != want {
+		t.Errorf("formatFirebaseRules() error = %v, want %s", err, want)
+	}
+}
@@ -0,0 +1,206 @@
+package sidkik
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type firebaseRulesTokenKind int
+
+const (
+	firebaseRulesTokenIdent firebaseRulesTokenKind = iota
+	firebaseRulesTokenNumber
+	firebaseRulesTokenString
+	firebaseRulesTokenPunct
+	firebaseRulesTokenComment
+)
+
+// firebaseRulesToken is a single token of Firebase Rules source. line and
+// column are 1-based, column counting runes; offset is the byte offset of the
+// token in the source.
+type firebaseRulesToken struct {
+	kind   firebaseRulesTokenKind
+	text   string
+	line   int
+	column int
+	offset int
+}
+
+// firebaseRulesOperators are the multi-character operators of the rules
+// language. Any other non-space character is a single character token.
+var firebaseRulesOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "**"}
+
+type firebaseRulesLexer struct {
+	src    string
+	pos    int
+	line   int
+	column int
+}
+
+// lexFirebaseRules splits Firebase Rules source into tokens, including
+// comments. Whitespace is dropped.
+func lexFirebaseRules(src string) ([]firebaseRulesToken, error) {
+	l := &firebaseRulesLexer{src: src, line: 1, column: 1}
+
+	tokens := make([]firebaseRulesToken, 0)
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.src) {
+			return tokens, nil
+		}
+
+		tok := firebaseRulesToken{line: l.line, column: l.column, offset: l.pos}
+		r := l.peek()
+		switch {
+		case strings.HasPrefix(l.src[l.pos:], "//"):
+			tok.kind = firebaseRulesTokenComment
+			for l.pos < len(l.src) && l.peek() != '\n' {
+				l.next()
+			}
+		case strings.HasPrefix(l.src[l.pos:], "/*"):
+			tok.kind = firebaseRulesTokenComment
+			end := strings.Index(l.src[l.pos+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%d:%d: unterminated comment", tok.line, tok.column)
+			}
+			for stop := l.pos + 2 + end + 2; l.pos < stop; {
+				l.next()
+			}
+		case r == '\'' || r == '"':
+			tok.kind = firebaseRulesTokenString
+			if err := l.lexString(r); err != nil {
+				return nil, fmt.Errorf("%d:%d: %s", tok.line, tok.column, err)
+			}
+		case unicode.IsDigit(r):
+			tok.kind = firebaseRulesTokenNumber
+			l.lexNumber()
+		case r == '_' || unicode.IsLetter(r):
+			tok.kind = firebaseRulesTokenIdent
+			for l.pos < len(l.src) && isFirebaseRulesIdentRune(l.peek()) {
+				l.next()
+			}
+		default:
+			tok.kind = firebaseRulesTokenPunct
+			l.lexPunct()
+		}
+
+		tok.text = l.src[tok.offset:l.pos]
+		tokens = append(tokens, tok)
+	}
+}
+
+func (l *firebaseRulesLexer) peek() rune {
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return r
+}
+
+func (l *firebaseRulesLexer) next() rune {
+	r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+	l.pos += size
+	if r == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+	return r
+}
+
+func (l *firebaseRulesLexer) skipSpace() {
+	for l.pos < len(l.src) && unicode.IsSpace(l.peek()) {
+		l.next()
+	}
+}
+
+func (l *firebaseRulesLexer) lexString(quote rune) error {
+	l.next()
+	for l.pos < len(l.src) {
+		switch l.next() {
+		case '\\':
+			if l.pos < len(l.src) {
+				l.next()
+			}
+		case quote:
+			return nil
+		case '\n':
+			return fmt.Errorf("newline in string")
+		}
+	}
+	return fmt.Errorf("unterminated string")
+}
+
+func (l *firebaseRulesLexer) lexNumber() {
+	for l.pos < len(l.src) {
+		r := l.peek()
+		if isFirebaseRulesIdentRune(r) {
+			l.next()
+			continue
+		}
+		// only a decimal point followed by a digit belongs to the number,
+		// e.g. 1.5 but not 1.toString()
+		if r == '.' && l.pos+1 < len(l.src) && l.src[l.pos+1] >= '0' && l.src[l.pos+1] <= '9' {
+			l.next()
+			continue
+		}
+		return
+	}
+}
+
+func (l *firebaseRulesLexer) lexPunct() {
+	for _, op := range firebaseRulesOperators {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			for range op {
+				l.next()
+			}
+			return
+		}
+	}
+	l.next()
+}
+
+func isFirebaseRulesIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// firebaseRulesSignificantTokens drops comments from tokens.
+func firebaseRulesSignificantTokens(tokens []firebaseRulesToken) []firebaseRulesToken {
+	significant := make([]firebaseRulesToken, 0, len(tokens))
+	for _, tok := range tokens {
+		if tok.kind != firebaseRulesTokenComment {
+			significant = append(significant, tok)
+		}
+	}
+	return significant
+}
+
+// firebaseRulesSourceEquivalent reports whether two rule sources only differ
+// in whitespace and comments. Sources that cannot be tokenized are compared
+// byte for byte.
+func firebaseRulesSourceEquivalent(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	aTokens, err := lexFirebaseRules(a)
+	if err != nil {
+		return false
+	}
+	bTokens, err := lexFirebaseRules(b)
+	if err != nil {
+		return false
+	}
+
+	aTokens = firebaseRulesSignificantTokens(aTokens)
+	bTokens = firebaseRulesSignificantTokens(bTokens)
+	if len(aTokens) != len(bTokens) {
+		return false
+	}
+	for i := range aTokens {
+		if aTokens[i].kind != bTokens[i].kind || aTokens[i].text != bTokens[i].text {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package sidkik
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_lexFirebaseRules(t *testing.T) {
+	src := "match /users/{userId} {\n" +
+		"  // owner only\n" +
+		"  allow read: if request.auth.uid == userId && 'a\\'b' != \"c\" || 1.5 > 0x1F;\n" +
+		"}"
+
+	type token struct {
+		kind   firebaseRulesTokenKind
+		text   string
+		line   int
+		column int
+	}
+	want := []token{
+		{firebaseRulesTokenIdent, "match", 1, 1},
+		{firebaseRulesTokenPunct, "/", 1, 7},
+		{firebaseRulesTokenIdent, "users", 1, 8},
+		{firebaseRulesTokenPunct, "/", 1, 13},
+		{firebaseRulesTokenPunct, "{", 1, 14},
+		{firebaseRulesTokenIdent, "userId", 1, 15},
+		{firebaseRulesTokenPunct, "}", 1, 21},
+		{firebaseRulesTokenPunct, "{", 1, 23},
+		{firebaseRulesTokenComment, "// owner only", 2, 3},
+		{firebaseRulesTokenIdent, "allow", 3, 3},
+		{firebaseRulesTokenIdent, "read", 3, 9},
+		{firebaseRulesTokenPunct, ":", 3, 13},
+		{firebaseRulesTokenIdent, "if", 3, 15},
+		{firebaseRulesTokenIdent, "request", 3, 18},
+		{firebaseRulesTokenPunct, ".", 3, 25},
+		{firebaseRulesTokenIdent, "auth", 3, 26},
+		{firebaseRulesTokenPunct, ".", 3, 30},
+		{firebaseRulesTokenIdent, "uid", 3, 31},
+		{firebaseRulesTokenPunct, "==", 3, 35},
+		{firebaseRulesTokenIdent, "userId", 3, 38},
+		{firebaseRulesTokenPunct, "&&", 3, 45},
+		{firebaseRulesTokenString, `'a\'b'`, 3, 48},
+		{firebaseRulesTokenPunct, "!=", 3, 55},
+		{firebaseRulesTokenString, `"c"`, 3, 58},
+		{firebaseRulesTokenPunct, "||", 3, 62},
+		{firebaseRulesTokenNumber, "1.5", 3, 65},
+		{firebaseRulesTokenPunct, ">", 3, 69},
+		{firebaseRulesTokenNumber, "0x1F", 3, 71},
+		{firebaseRulesTokenPunct, ";", 3, 75},
+		{firebaseRulesTokenPunct, "}", 4, 1},
+	}
+
+	tokens, err := lexFirebaseRules(src)
+	if err != nil {
+		t.Fatalf("lexFirebaseRules() error = %v", err)
+	}
+	got := make([]token, 0, len(tokens))
+	for _, tok := range tokens {
+		got = append(got, token{tok.kind, tok.text, tok.line, tok.column})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lexFirebaseRules() = %v, want %v", got, want)
+	}
+}
+
+func Test_lexFirebaseRulesErrors(t *testing.T) {
+	tests := map[string]string{
+		"allow read: if x == 'abc;":    "1:21: unterminated string",
+		"allow read: if x == 'a\nbc';": "1:21: newline in string",
+		"service x {\n  /* comment\n}": "2:3: unterminated comment",
+	}
+	for src, want := range tests {
+		if _, err := lexFirebaseRules(src); err == nil || err.Error() != want {
+			t.Errorf("lexFirebaseRules(%q) error = %v, want %q", src, err, want)
+		}
+	}
+}
+
+func Test_firebaseRulesSourceEquivalent(t *testing.T) {
+	base := "rules_version = '2';\nservice cloud.firestore {\n  match /databases/{database}/documents {\n    allow read: if true;\n  }\n}\n"
+
+	tests := []struct {
+		name string
+		src  string
+		want bool
+	}{
+		{
+			name: "identical",
+			src:  base,
+			want: true,
+		},
+		{
+			name: "indentation and trailing newlines",
+			src:  "\t\trules_version = '2';\n\t\tservice cloud.firestore {\n\t\t\tmatch /databases/{database}/documents {\n\t\t\t\tallow read: if true;\n\t\t\t}\n\t\t}\n\n\n",
+			want: true,
+		},
+		{
+			name: "comments",
+			src:  "// header\nrules_version = '2';\nservice cloud.firestore { /* block */\n  match /databases/{database}/documents {\n    allow read: if true; // trailing\n  }\n}",
+			want: true,
+		},
+		{
+			name: "whitespace inside strings",
+			src:  "rules_version = ' 2';\nservice cloud.firestore {\n  match /databases/{database}/documents {\n    allow read: if true;\n  }\n}\n",
+			want: false,
+		},
+		{
+			name: "semantic change",
+			src:  "rules_version = '2';\nservice cloud.firestore {\n  match /databases/{database}/documents {\n    allow read: if false;\n  }\n}\n",
+			want: false,
+		},
+		{
+			name: "split identifier",
+			src:  "rules_version = '2';\nservice cloud.fire store {\n  match /databases/{database}/documents {\n    allow read: if true;\n  }\n}\n",
+			want: false,
+		},
+		{
+			name: "unterminated string",
+			src:  "rules_version = '2;",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := firebaseRulesSourceEquivalent(base, tt.src); got != tt.want {
+				t.Errorf("firebaseRulesSourceEquivalent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
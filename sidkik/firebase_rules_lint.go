@@ -0,0 +1,320 @@
+package sidkik
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// firebaseRulesLintFinding is a dangerous pattern found in rule source.
+type firebaseRulesLintFinding struct {
+	file    string
+	pos     firebaseRulesPos
+	check   string
+	message string
+}
+
+func (f firebaseRulesLintFinding) String() string {
+	return fmt.Sprintf("%s:%s: %s (%s)", f.file, f.pos, f.message, f.check)
+}
+
+// lintFirebaseRulesFiles lints each source file. Functions are shared across
+// files, as they are in a ruleset. Files that do not parse are skipped since
+// validation reports their syntax errors.
+func lintFirebaseRulesFiles(files []interface{}) []firebaseRulesLintFinding {
+	parsed := make(map[string]*firebaseRulesFile)
+	names := make([]string, 0, len(files))
+	functions := make(map[string][]*firebaseRulesFunction)
+	for _, raw := range files {
+		file := raw.(map[string]interface{})
+		name := fmt.Sprintf("%v", file["name"])
+		content, _ := file["content"].(string)
+
+		rules, err := parseFirebaseRules(content)
+		if err != nil {
+			log.Printf("[DEBUG] Not linting %s: %s", name, err)
+			continue
+		}
+		parsed[name] = rules
+		names = append(names, name)
+		collectFirebaseRulesFunctions(rules.body, functions)
+	}
+
+	findings := make([]firebaseRulesLintFinding, 0)
+	for _, name := range names {
+		l := &firebaseRulesLinter{file: name, functions: functions}
+		l.lintBody(parsed[name].body, false)
+		findings = append(findings, l.findings...)
+	}
+	return findings
+}
+
+func collectFirebaseRulesFunctions(body []interface{}, functions map[string][]*firebaseRulesFunction) {
+	for _, stmt := range body {
+		switch s := stmt.(type) {
+		case *firebaseRulesFunction:
+			functions[s.name] = append(functions[s.name], s)
+		case *firebaseRulesService:
+			collectFirebaseRulesFunctions(s.body, functions)
+		case *firebaseRulesMatch:
+			collectFirebaseRulesFunctions(s.body, functions)
+		}
+	}
+}
+
+type firebaseRulesLinter struct {
+	file      string
+	functions map[string][]*firebaseRulesFunction
+	findings  []firebaseRulesLintFinding
+}
+
+func (l *firebaseRulesLinter) report(pos firebaseRulesPos, check, format string, args ...interface{}) {
+	l.findings = append(l.findings, firebaseRulesLintFinding{
+		file:    l.file,
+		pos:     pos,
+		check:   check,
+		message: fmt.Sprintf(format, args...),
+	})
+}
+
+// lintBody lints the statements of a service or match block. recursive is set
+// for the direct body of a match with a {name=**} wildcard.
+func (l *firebaseRulesLinter) lintBody(body []interface{}, recursive bool) {
+	for _, stmt := range body {
+		switch s := stmt.(type) {
+		case *firebaseRulesService:
+			l.lintBody(s.body, false)
+		case *firebaseRulesMatch:
+			l.lintBody(s.body, firebaseRulesPathIsRecursive(s.path))
+		case *firebaseRulesAllow:
+			l.lintAllow(s, recursive)
+		}
+	}
+}
+
+func (l *firebaseRulesLinter) lintAllow(allow *firebaseRulesAllow, recursive bool) {
+	if isFirebaseRulesLiteral(allow.condition, "false") {
+		return
+	}
+	methods := strings.Join(allow.methods, ", ")
+	writes := firebaseRulesAllowsAny(allow, "write", "create", "update", "delete")
+
+	if allow.condition == nil || isFirebaseRulesLiteral(allow.condition, "true") {
+		l.report(allow.pos, "allow-if-true", "allow %s grants access unconditionally", methods)
+	} else if writes && !l.references(allow.condition, isFirebaseRulesAuth, map[string]bool{}) {
+		l.report(allow.pos, "write-without-auth", "allow %s does not check request.auth", methods)
+	}
+
+	if recursive && writes {
+		l.report(allow.pos, "recursive-wildcard-write", "allow %s applies to every path below a recursive wildcard", methods)
+	}
+
+	// write also covers update and delete, where resource.data is the stored
+	// document, so only an explicit create is reported
+	if allow.condition != nil && firebaseRulesAllowsAny(allow, "create") && l.references(allow.condition, isFirebaseRulesResourceData, map[string]bool{}) {
+		l.report(allow.pos, "resource-data-on-create", "allow %s reads resource.data, which does not exist on create; use request.resource.data", methods)
+	}
+}
+
+// references reports whether expr, or a function it calls, contains an
+// expression matching match.
+func (l *firebaseRulesLinter) references(expr firebaseRulesExpr, match func(firebaseRulesExpr) bool, visited map[string]bool) bool {
+	found := false
+	inspectFirebaseRulesExpr(expr, func(e firebaseRulesExpr) bool {
+		if found {
+			return false
+		}
+		if match(e) {
+			found = true
+			return false
+		}
+		call, ok := e.(*firebaseRulesCallExpr)
+		if !ok {
+			return true
+		}
+		ident, ok := call.fn.(*firebaseRulesIdentExpr)
+		if !ok || visited[ident.name] {
+			return true
+		}
+		visited[ident.name] = true
+		for _, function := range l.functions[ident.name] {
+			for _, let := range function.lets {
+				if l.references(let.value, match, visited) {
+					found = true
+				}
+			}
+			if l.references(function.result, match, visited) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// inspectFirebaseRulesExpr calls f for expr and each of its sub-expressions in
+// depth first order, skipping the children of expressions f returns false for.
+func inspectFirebaseRulesExpr(expr firebaseRulesExpr, f func(firebaseRulesExpr) bool) {
+	if expr == nil || !f(expr) {
+		return
+	}
+
+	children := make([]firebaseRulesExpr, 0)
+	switch e := expr.(type) {
+	case *firebaseRulesParenExpr:
+		children = append(children, e.x)
+	case *firebaseRulesUnaryExpr:
+		children = append(children, e.x)
+	case *firebaseRulesBinaryExpr:
+		children = append(children, e.x, e.y)
+	case *firebaseRulesTernaryExpr:
+		children = append(children, e.cond, e.x, e.y)
+	case *firebaseRulesMemberExpr:
+		children = append(children, e.x)
+	case *firebaseRulesIndexExpr:
+		children = append(children, e.x, e.index, e.end)
+	case *firebaseRulesCallExpr:
+		children = append(children, e.fn)
+		children = append(children, e.args...)
+	case *firebaseRulesListExpr:
+		children = append(children, e.elems...)
+	case *firebaseRulesMapExpr:
+		children = append(children, e.keys...)
+		children = append(children, e.values...)
+	case *firebaseRulesPathExpr:
+		for _, segment := range e.path.segments {
+			children = append(children, segment.expr)
+		}
+	}
+
+	for _, child := range children {
+		inspectFirebaseRulesExpr(child, f)
+	}
+}
+
+// isFirebaseRulesAuth matches request.auth.
+func isFirebaseRulesAuth(e firebaseRulesExpr) bool {
+	member, ok := e.(*firebaseRulesMemberExpr)
+	if !ok || member.name != "auth" {
+		return false
+	}
+	ident, ok := member.x.(*firebaseRulesIdentExpr)
+	return ok && ident.name == "request"
+}
+
+// isFirebaseRulesResourceData matches resource.data, but not
+// request.resource.data.
+func isFirebaseRulesResourceData(e firebaseRulesExpr) bool {
+	member, ok := e.(*firebaseRulesMemberExpr)
+	if !ok || member.name != "data" {
+		return false
+	}
+	ident, ok := member.x.(*firebaseRulesIdentExpr)
+	return ok && ident.name == "resource"
+}
+
+func isFirebaseRulesLiteral(e firebaseRulesExpr, text string) bool {
+	for {
+		paren, ok := e.(*firebaseRulesParenExpr)
+		if !ok {
+			break
+		}
+		e = paren.x
+	}
+	literal, ok := e.(*firebaseRulesLiteralExpr)
+	return ok && literal.text == text
+}
+
+func firebaseRulesAllowsAny(allow *firebaseRulesAllow, methods ...string) bool {
+	for _, method := range allow.methods {
+		if stringInSlice(methods, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func firebaseRulesPathIsRecursive(path *firebaseRulesPath) bool {
+	for _, segment := range path.segments {
+		if segment.recursive {
+			return true
+		}
+	}
+	return false
+}
+
+// firebaseRulesLintLevel returns the provider's rules_lint_level, which
+// defaults to warning.
+func firebaseRulesLintLevel(config *Config) string {
+	if config.RulesLintLevel == "" {
+		return "warning"
+	}
+	return config.RulesLintLevel
+}
+
+// firebaseRulesLintCustomizeDiff lints changed rule source during plan. With
+// rules_lint_level = "error" any finding fails the plan. A CustomizeDiff cannot
+// return warnings, so with "warning" the findings are only logged here and
+// reported by firebaseRulesLintWarnings once the source is released.
+func firebaseRulesLintCustomizeDiff(ruleType string) schema.CustomizeDiffFunc {
+	return func(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		level := firebaseRulesLintLevel(meta.(*Config))
+		if level == "off" || !firebaseRulesSourceHasChange(d) || !firebaseRulesSourceKnown(d) {
+			return nil
+		}
+
+		files, err := firebaseRulesSourceFiles(d.Get("rule"), d.Get("rule_file"), d.Get("files"), ruleType)
+		if err != nil {
+			return err
+		}
+
+		findings := lintFirebaseRulesFiles(files)
+		if len(findings) == 0 {
+			return nil
+		}
+
+		lines := make([]string, 0, len(findings))
+		for _, finding := range findings {
+			lines = append(lines, finding.String())
+		}
+		if level == "error" {
+			return fmt.Errorf("Firebase Rules lint found %d problems:\n%s", len(findings), strings.Join(lines, "\n"))
+		}
+
+		log.Printf("[WARN] Firebase Rules lint found %d problems:\n%s", len(findings), strings.Join(lines, "\n"))
+		return nil
+	}
+}
+
+// firebaseRulesLintWarnings wraps the create or update function of a rule
+// resource so that lint findings on newly released source are reported as
+// warnings once it is applied.
+func firebaseRulesLintWarnings(ruleType string, f func(*schema.ResourceData, interface{}) error) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+	return func(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		released := d.Id() == "" || d.HasChange("rule") || d.HasChange("files") || d.HasChange("source_sha256")
+
+		files, filesErr := firebaseRulesSourceFiles(d.Get("rule"), d.Get("rule_file"), d.Get("files"), ruleType)
+
+		if err := f(d, meta); err != nil {
+			return diag.FromErr(err)
+		}
+
+		if !released || filesErr != nil || firebaseRulesLintLevel(meta.(*Config)) != "warning" {
+			return nil
+		}
+
+		var diags diag.Diagnostics
+		for _, finding := range lintFirebaseRulesFiles(files) {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  fmt.Sprintf("Firebase Rules lint: %s", finding.message),
+				Detail:   fmt.Sprintf("%s at %s:%s. Set rules_lint_level to \"off\" to silence or \"error\" to enforce.", finding.check, finding.file, finding.pos),
+			})
+		}
+		return diags
+	}
+}
@@ -0,0 +1,158 @@
+package sidkik
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_lintFirebaseRulesFiles(t *testing.T) {
+	cases := map[string]struct {
+		files    []interface{}
+		expected []string
+	}{
+		"safe": {
+			files: []interface{}{map[string]interface{}{"name": "firestore.rules", "content": `rules_version = '2';
+service cloud.firestore {
+  match /databases/{database}/documents {
+    function isOwner(userId) {
+      return request.auth != null && request.auth.uid == userId;
+    }
+
+    match /users/{userId} {
+      allow read: if isOwner(userId);
+      allow create: if isOwner(userId) && request.resource.data.owner == userId;
+      allow update: if isOwner(userId) && resource.data.owner == userId;
+      allow delete: if false;
+    }
+
+    match /{document=**} {
+      allow read, write: if false;
+    }
+  }
+}
+`}},
+			expected: []string{},
+		},
+		"if true": {
+			files: []interface{}{map[string]interface{}{"name": "storage.rules", "content": `rules_version = '2';
+service firebase.storage {
+  match /b/{bucket}/o {
+    match /public/{file} {
+      allow read;
+      allow write: if (true);
+    }
+  }
+}
+`}},
+			expected: []string{
+				"storage.rules:5:7: allow read grants access unconditionally (allow-if-true)",
+				"storage.rules:6:7: allow write grants access unconditionally (allow-if-true)",
+			},
+		},
+		"write without auth": {
+			files: []interface{}{map[string]interface{}{"name": "firestore.rules", "content": `service cloud.firestore {
+  match /databases/{database}/documents {
+    match /posts/{post} {
+      allow read: if request.time < timestamp.date(2030, 1, 1);
+      allow update, delete: if request.resource.data.size() < 10;
+    }
+  }
+}
+`}},
+			expected: []string{
+				"firestore.rules:5:7: allow update, delete does not check request.auth (write-without-auth)",
+			},
+		},
+		"recursive wildcard write": {
+			files: []interface{}{map[string]interface{}{"name": "firestore.rules", "content": `service cloud.firestore {
+  match /databases/{database}/documents {
+    match /{document=**} {
+      allow read: if request.auth != null;
+      allow write: if request.auth.token.admin == true;
+    }
+  }
+}
+`}},
+			expected: []string{
+				"firestore.rules:5:7: allow write applies to every path below a recursive wildcard (recursive-wildcard-write)",
+			},
+		},
+		"resource data on create": {
+			files: []interface{}{map[string]interface{}{"name": "firestore.rules", "content": `service cloud.firestore {
+  match /databases/{database}/documents {
+    function owns() {
+      return resource.data.owner == request.auth.uid;
+    }
+
+    match /notes/{note} {
+      allow create: if owns();
+      allow update: if owns();
+    }
+  }
+}
+`}},
+			expected: []string{
+				"firestore.rules:8:7: allow create reads resource.data, which does not exist on create; use request.resource.data (resource-data-on-create)",
+			},
+		},
+		"resource data on write": {
+			files: []interface{}{map[string]interface{}{"name": "firestore.rules", "content": `service cloud.firestore {
+  match /databases/{database}/documents {
+    match /notes/{note} {
+      allow write: if resource.data.owner == request.auth.uid;
+    }
+  }
+}
+`}},
+			expected: []string{},
+		},
+		"functions shared across files": {
+			files: []interface{}{
+				map[string]interface{}{"name": "functions.rules", "content": `function isSignedIn() {
+  return request.auth != null;
+}
+`},
+				map[string]interface{}{"name": "firestore.rules", "content": `service cloud.firestore {
+  match /databases/{database}/documents {
+    match /notes/{note} {
+      allow write: if isSignedIn();
+    }
+  }
+}
+`},
+			},
+			expected: []string{},
+		},
+		"recursive functions": {
+			files: []interface{}{map[string]interface{}{"name": "firestore.rules", "content": `service cloud.firestore {
+  match /databases/{database}/documents {
+    function a() { return b(); }
+    function b() { return a(); }
+
+    match /notes/{note} {
+      allow write: if a();
+    }
+  }
+}
+`}},
+			expected: []string{
+				"firestore.rules:7:7: allow write does not check request.auth (write-without-auth)",
+			},
+		},
+		"invalid source": {
+			files:    []interface{}{map[string]interface{}{"name": "firestore.rules", "content": `service cloud.firestore { allow write: if true; }`}},
+			expected: []string{},
+		},
+	}
+
+	for tn, tc := range cases {
+		findings := lintFirebaseRulesFiles(tc.files)
+		actual := make([]string, 0, len(findings))
+		for _, finding := range findings {
+			actual = append(actual, finding.String())
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("bad: %s, expected %q, got %q", tn, tc.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,788 @@
+package sidkik
+
+import (
+	"fmt"
+	"strings"
+)
+
+// firebaseRulesPos is the 1-based line and column of a node in rule source.
+type firebaseRulesPos struct {
+	line   int
+	column int
+}
+
+func (p firebaseRulesPos) String() string {
+	return fmt.Sprintf("%d:%d", p.line, p.column)
+}
+
+// firebaseRulesFile is the root of a parsed rules file. body holds the
+// top-level *firebaseRulesService and *firebaseRulesFunction declarations in
+// source order.
+type firebaseRulesFile struct {
+	version string
+	body    []interface{}
+}
+
+type firebaseRulesService struct {
+	pos  firebaseRulesPos
+	name string
+	body []interface{}
+}
+
+// firebaseRulesMatch is a match block. body holds nested *firebaseRulesMatch,
+// *firebaseRulesAllow and *firebaseRulesFunction statements in source order.
+type firebaseRulesMatch struct {
+	pos  firebaseRulesPos
+	path *firebaseRulesPath
+	body []interface{}
+}
+
+// firebaseRulesAllow is an allow statement. condition is nil for an allow
+// without an if clause.
+type firebaseRulesAllow struct {
+	pos       firebaseRulesPos
+	methods   []string
+	condition firebaseRulesExpr
+}
+
+type firebaseRulesFunction struct {
+	pos    firebaseRulesPos
+	name   string
+	params []string
+	lets   []*firebaseRulesLet
+	result firebaseRulesExpr
+}
+
+type firebaseRulesLet struct {
+	pos   firebaseRulesPos
+	name  string
+	value firebaseRulesExpr
+}
+
+// firebaseRulesPath is a match path or a path literal in an expression, e.g.
+// /databases/{database}/documents or /databases/$(database)/documents/users.
+type firebaseRulesPath struct {
+	pos      firebaseRulesPos
+	segments []*firebaseRulesPathSegment
+}
+
+// firebaseRulesPathSegment is exactly one of a literal segment, a wildcard
+// such as {name} or {name=**}, or an $(expr) interpolation.
+type firebaseRulesPathSegment struct {
+	text      string
+	wildcard  string
+	recursive bool
+	expr      firebaseRulesExpr
+}
+
+type firebaseRulesExpr interface {
+	position() firebaseRulesPos
+}
+
+type firebaseRulesIdentExpr struct {
+	pos  firebaseRulesPos
+	name string
+}
+
+// firebaseRulesLiteralExpr is a number, string, bool or null literal. text is
+// the literal as written, including quotes and any r or b string prefix.
+type firebaseRulesLiteralExpr struct {
+	pos  firebaseRulesPos
+	text string
+}
+
+type firebaseRulesParenExpr struct {
+	pos firebaseRulesPos
+	x   firebaseRulesExpr
+}
+
+type firebaseRulesUnaryExpr struct {
+	pos firebaseRulesPos
+	op  string
+	x   firebaseRulesExpr
+}
+
+type firebaseRulesBinaryExpr struct {
+	pos firebaseRulesPos
+	op  string
+	x   firebaseRulesExpr
+	y   firebaseRulesExpr
+}
+
+type firebaseRulesTernaryExpr struct {
+	pos  firebaseRulesPos
+	cond firebaseRulesExpr
+	x    firebaseRulesExpr
+	y    firebaseRulesExpr
+}
+
+type firebaseRulesMemberExpr struct {
+	pos  firebaseRulesPos
+	x    firebaseRulesExpr
+	name string
+}
+
+// firebaseRulesIndexExpr is x[index] or, when slice is set, x[index:end].
+type firebaseRulesIndexExpr struct {
+	pos   firebaseRulesPos
+	x     firebaseRulesExpr
+	index firebaseRulesExpr
+	end   firebaseRulesExpr
+	slice bool
+}
+
+type firebaseRulesCallExpr struct {
+	pos  firebaseRulesPos
+	fn   firebaseRulesExpr
+	args []firebaseRulesExpr
+}
+
+type firebaseRulesListExpr struct {
+	pos   firebaseRulesPos
+	elems []firebaseRulesExpr
+}
+
+type firebaseRulesMapExpr struct {
+	pos    firebaseRulesPos
+	keys   []firebaseRulesExpr
+	values []firebaseRulesExpr
+}
+
+type firebaseRulesPathExpr struct {
+	path *firebaseRulesPath
+}
+
+func (e *firebaseRulesIdentExpr) position() firebaseRulesPos   { return e.pos }
+func (e *firebaseRulesLiteralExpr) position() firebaseRulesPos { return e.pos }
+func (e *firebaseRulesParenExpr) position() firebaseRulesPos   { return e.pos }
+func (e *firebaseRulesUnaryExpr) position() firebaseRulesPos   { return e.pos }
+func (e *firebaseRulesBinaryExpr) position() firebaseRulesPos  { return e.pos }
+func (e *firebaseRulesTernaryExpr) position() firebaseRulesPos { return e.pos }
+func (e *firebaseRulesMemberExpr) position() firebaseRulesPos  { return e.pos }
+func (e *firebaseRulesIndexExpr) position() firebaseRulesPos   { return e.pos }
+func (e *firebaseRulesCallExpr) position() firebaseRulesPos    { return e.pos }
+func (e *firebaseRulesListExpr) position() firebaseRulesPos    { return e.pos }
+func (e *firebaseRulesMapExpr) position() firebaseRulesPos     { return e.pos }
+func (e *firebaseRulesPathExpr) position() firebaseRulesPos    { return e.path.pos }
+
+// firebaseRulesMethods are the methods an allow statement may grant.
+var firebaseRulesMethods = []string{"read", "write", "get", "list", "create", "update", "delete"}
+
+// firebaseRulesBinaryPrecedence maps binary operators to their precedence,
+// higher binding tighter.
+var firebaseRulesBinaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3, "<": 3, "<=": 3, ">": 3, ">=": 3, "in": 3, "is": 3,
+	"+": 4, "-": 4,
+	"*": 5, "/": 5, "%": 5,
+}
+
+type firebaseRulesParser struct {
+	tokens []firebaseRulesToken
+	pos    int
+	eof    firebaseRulesPos
+}
+
+// parseFirebaseRules parses rule source into a syntax tree. Errors are
+// prefixed with the line and column they were found at.
+func parseFirebaseRules(src string) (*firebaseRulesFile, error) {
+	tokens, err := lexFirebaseRules(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &firebaseRulesParser{
+		tokens: firebaseRulesSignificantTokens(tokens),
+		eof:    firebaseRulesEndPos(src),
+	}
+	return p.parseFile()
+}
+
+// firebaseRulesEndPos returns the position just past the end of src.
+func firebaseRulesEndPos(src string) firebaseRulesPos {
+	line := strings.Count(src, "\n") + 1
+	last := src[strings.LastIndex(src, "\n")+1:]
+	return firebaseRulesPos{line: line, column: len([]rune(last)) + 1}
+}
+
+func (p *firebaseRulesParser) peek() *firebaseRulesToken {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos]
+}
+
+func (p *firebaseRulesParser) next() *firebaseRulesToken {
+	tok := p.peek()
+	if tok != nil {
+		p.pos++
+	}
+	return tok
+}
+
+// is reports whether the next token is a punctuation or identifier token
+// with the given text.
+func (p *firebaseRulesParser) is(text string) bool {
+	tok := p.peek()
+	return tok != nil && tok.text == text && (tok.kind == firebaseRulesTokenPunct || tok.kind == firebaseRulesTokenIdent)
+}
+
+// accept consumes the next token if it has the given text.
+func (p *firebaseRulesParser) accept(text string) bool {
+	if p.is(text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *firebaseRulesParser) expect(text string) (*firebaseRulesToken, error) {
+	if !p.is(text) {
+		return nil, p.unexpected(fmt.Sprintf("%q", text))
+	}
+	return p.next(), nil
+}
+
+func (p *firebaseRulesParser) expectIdent(what string) (*firebaseRulesToken, error) {
+	tok := p.peek()
+	if tok == nil || tok.kind != firebaseRulesTokenIdent {
+		return nil, p.unexpected(what)
+	}
+	return p.next(), nil
+}
+
+// adjacent reports whether the next token directly follows the previous one,
+// without whitespace or comments in between.
+func (p *firebaseRulesParser) adjacent() bool {
+	if p.pos == 0 || p.pos >= len(p.tokens) {
+		return false
+	}
+	prev := p.tokens[p.pos-1]
+	return p.tokens[p.pos].offset == prev.offset+len(prev.text)
+}
+
+func (p *firebaseRulesParser) position() firebaseRulesPos {
+	if tok := p.peek(); tok != nil {
+		return firebaseRulesPos{line: tok.line, column: tok.column}
+	}
+	return p.eof
+}
+
+func (p *firebaseRulesParser) errorf(pos firebaseRulesPos, format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %s", pos, fmt.Sprintf(format, args...))
+}
+
+func (p *firebaseRulesParser) unexpected(want string) error {
+	tok := p.peek()
+	if tok == nil {
+		return p.errorf(p.eof, "expected %s, found end of rules", want)
+	}
+	return p.errorf(p.position(), "expected %s, found %q", want, tok.text)
+}
+
+func (p *firebaseRulesParser) parseFile() (*firebaseRulesFile, error) {
+	file := &firebaseRulesFile{}
+
+	if p.accept("rules_version") {
+		if _, err := p.expect("="); err != nil {
+			return nil, err
+		}
+		tok := p.peek()
+		if tok == nil || tok.kind != firebaseRulesTokenString {
+			return nil, p.unexpected("rules version string")
+		}
+		file.version = p.next().text
+		p.accept(";")
+	}
+
+	for p.peek() != nil {
+		switch {
+		case p.is("service"):
+			service, err := p.parseService()
+			if err != nil {
+				return nil, err
+			}
+			file.body = append(file.body, service)
+		case p.is("function"):
+			function, err := p.parseFunction()
+			if err != nil {
+				return nil, err
+			}
+			file.body = append(file.body, function)
+		default:
+			return nil, p.unexpected("service or function")
+		}
+	}
+
+	return file, nil
+}
+
+func (p *firebaseRulesParser) parseService() (*firebaseRulesService, error) {
+	service := &firebaseRulesService{pos: p.position()}
+	p.next()
+
+	name, err := p.expectIdent("service name")
+	if err != nil {
+		return nil, err
+	}
+	service.name = name.text
+	for p.accept(".") {
+		part, err := p.expectIdent("service name")
+		if err != nil {
+			return nil, err
+		}
+		service.name += "." + part.text
+	}
+
+	if service.body, err = p.parseBlock(false); err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+// parseBlock parses a braced list of match, allow and function statements.
+// allow statements are only valid within match blocks.
+func (p *firebaseRulesParser) parseBlock(inMatch bool) ([]interface{}, error) {
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	body := make([]interface{}, 0)
+	for !p.accept("}") {
+		switch {
+		case p.is("match"):
+			match, err := p.parseMatch()
+			if err != nil {
+				return nil, err
+			}
+			body = append(body, match)
+		case p.is("allow"):
+			if !inMatch {
+				return nil, p.errorf(p.position(), "allow must be inside a match block")
+			}
+			allow, err := p.parseAllow()
+			if err != nil {
+				return nil, err
+			}
+			body = append(body, allow)
+		case p.is("function"):
+			function, err := p.parseFunction()
+			if err != nil {
+				return nil, err
+			}
+			body = append(body, function)
+		default:
+			if inMatch {
+				return nil, p.unexpected(`match, allow, function or "}"`)
+			}
+			return nil, p.unexpected(`match, function or "}"`)
+		}
+	}
+	return body, nil
+}
+
+func (p *firebaseRulesParser) parseMatch() (*firebaseRulesMatch, error) {
+	match := &firebaseRulesMatch{pos: p.position()}
+	p.next()
+
+	path, err := p.parsePath(false)
+	if err != nil {
+		return nil, err
+	}
+	match.path = path
+
+	if match.body, err = p.parseBlock(true); err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+func (p *firebaseRulesParser) parseAllow() (*firebaseRulesAllow, error) {
+	allow := &firebaseRulesAllow{pos: p.position()}
+	p.next()
+
+	for {
+		method, err := p.expectIdent("method")
+		if err != nil {
+			return nil, err
+		}
+		if !stringInSlice(firebaseRulesMethods, method.text) {
+			return nil, p.errorf(firebaseRulesPos{method.line, method.column}, "unknown method %q, expected one of %s", method.text, strings.Join(firebaseRulesMethods, ", "))
+		}
+		allow.methods = append(allow.methods, method.text)
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	if p.accept(":") {
+		if _, err := p.expect("if"); err != nil {
+			return nil, err
+		}
+		condition, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		allow.condition = condition
+	}
+	p.accept(";")
+
+	return allow, nil
+}
+
+func (p *firebaseRulesParser) parseFunction() (*firebaseRulesFunction, error) {
+	function := &firebaseRulesFunction{pos: p.position()}
+	p.next()
+
+	name, err := p.expectIdent("function name")
+	if err != nil {
+		return nil, err
+	}
+	function.name = name.text
+
+	if _, err := p.expect("("); err != nil {
+		return nil, err
+	}
+	for !p.accept(")") {
+		if len(function.params) > 0 {
+			if _, err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		param, err := p.expectIdent("parameter name")
+		if err != nil {
+			return nil, err
+		}
+		function.params = append(function.params, param.text)
+	}
+
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for p.is("let") {
+		let := &firebaseRulesLet{pos: p.position()}
+		p.next()
+		name, err := p.expectIdent("variable name")
+		if err != nil {
+			return nil, err
+		}
+		let.name = name.text
+		if _, err := p.expect("="); err != nil {
+			return nil, err
+		}
+		if let.value, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		p.accept(";")
+		function.lets = append(function.lets, let)
+	}
+	if _, err := p.expect("return"); err != nil {
+		return nil, err
+	}
+	if function.result, err = p.parseExpr(); err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	if _, err := p.expect("}"); err != nil {
+		return nil, err
+	}
+
+	return function, nil
+}
+
+// parsePath parses a path made of adjacent tokens. Match paths may contain
+// wildcards, path literals in expressions may contain $(expr) interpolations.
+func (p *firebaseRulesParser) parsePath(inExpr bool) (*firebaseRulesPath, error) {
+	path := &firebaseRulesPath{pos: p.position()}
+	if _, err := p.expect("/"); err != nil {
+		return nil, err
+	}
+
+	for {
+		segment := &firebaseRulesPathSegment{}
+		switch {
+		case !p.adjacent():
+			return nil, p.errorf(p.position(), "expected path segment after %q", "/")
+		case p.is("{") && !inExpr:
+			p.next()
+			name, err := p.expectIdent("wildcard name")
+			if err != nil {
+				return nil, err
+			}
+			segment.wildcard = name.text
+			if p.accept("=") {
+				if _, err := p.expect("**"); err != nil {
+					return nil, err
+				}
+				segment.recursive = true
+			}
+			if _, err := p.expect("}"); err != nil {
+				return nil, err
+			}
+		case p.is("$") && inExpr:
+			p.next()
+			if !p.adjacent() {
+				return nil, p.unexpected(`"(" after "$"`)
+			}
+			if _, err := p.expect("("); err != nil {
+				return nil, err
+			}
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			segment.expr = expr
+		default:
+			// balanced parentheses are part of the segment, e.g. (default)
+			depth := 0
+			for p.adjacent() && (isFirebaseRulesPathToken(p.peek()) || p.is("(") || (p.is(")") && depth > 0)) {
+				switch p.next().text {
+				case "(":
+					depth++
+				case ")":
+					depth--
+				}
+				segment.text += p.tokens[p.pos-1].text
+			}
+			if depth > 0 {
+				return nil, p.unexpected(`")"`)
+			}
+			if segment.text == "" {
+				return nil, p.unexpected("path segment")
+			}
+		}
+		path.segments = append(path.segments, segment)
+
+		if !p.adjacent() || !p.is("/") {
+			return path, nil
+		}
+		p.next()
+	}
+}
+
+// isFirebaseRulesPathToken reports whether tok may be part of a literal path
+// segment.
+func isFirebaseRulesPathToken(tok *firebaseRulesToken) bool {
+	if tok.kind != firebaseRulesTokenPunct {
+		return true
+	}
+	return !strings.Contains("/{}$()[];,", tok.text)
+}
+
+func (p *firebaseRulesParser) parseExpr() (firebaseRulesExpr, error) {
+	cond, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+	if !p.is("?") {
+		return cond, nil
+	}
+
+	ternary := &firebaseRulesTernaryExpr{pos: cond.position(), cond: cond}
+	p.next()
+	if ternary.x, err = p.parseExpr(); err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	if ternary.y, err = p.parseExpr(); err != nil {
+		return nil, err
+	}
+	return ternary, nil
+}
+
+func (p *firebaseRulesParser) parseBinary(minPrecedence int) (firebaseRulesExpr, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		if tok == nil || tok.kind == firebaseRulesTokenString || tok.kind == firebaseRulesTokenNumber {
+			return x, nil
+		}
+		precedence, ok := firebaseRulesBinaryPrecedence[tok.text]
+		if !ok || precedence < minPrecedence {
+			return x, nil
+		}
+		p.next()
+
+		y, err := p.parseBinary(precedence + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = &firebaseRulesBinaryExpr{pos: x.position(), op: tok.text, x: x, y: y}
+	}
+}
+
+func (p *firebaseRulesParser) parseUnary() (firebaseRulesExpr, error) {
+	if p.is("!") || p.is("-") {
+		unary := &firebaseRulesUnaryExpr{pos: p.position(), op: p.next().text}
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		unary.x = x
+		return unary, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *firebaseRulesParser) parsePostfix() (firebaseRulesExpr, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		pos := x.position()
+		switch {
+		case p.accept("."):
+			name, err := p.expectIdent("member name")
+			if err != nil {
+				return nil, err
+			}
+			x = &firebaseRulesMemberExpr{pos: pos, x: x, name: name.text}
+		case p.accept("["):
+			index := &firebaseRulesIndexExpr{pos: pos, x: x}
+			if index.index, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+			if p.accept(":") {
+				index.slice = true
+				if index.end, err = p.parseExpr(); err != nil {
+					return nil, err
+				}
+			}
+			if _, err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = index
+		case p.accept("("):
+			call := &firebaseRulesCallExpr{pos: pos, fn: x}
+			if call.args, err = p.parseExprList(")"); err != nil {
+				return nil, err
+			}
+			x = call
+		default:
+			return x, nil
+		}
+	}
+}
+
+// parseExprList parses comma separated expressions up to and including the
+// closing token. A trailing comma is allowed.
+func (p *firebaseRulesParser) parseExprList(closing string) ([]firebaseRulesExpr, error) {
+	exprs := make([]firebaseRulesExpr, 0)
+	for !p.accept(closing) {
+		if len(exprs) > 0 {
+			if _, err := p.expect(","); err != nil {
+				return nil, err
+			}
+			if p.accept(closing) {
+				break
+			}
+		}
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	return exprs, nil
+}
+
+func (p *firebaseRulesParser) parsePrimary() (firebaseRulesExpr, error) {
+	tok := p.peek()
+	if tok == nil {
+		return nil, p.unexpected("expression")
+	}
+	pos := p.position()
+
+	switch tok.kind {
+	case firebaseRulesTokenNumber, firebaseRulesTokenString:
+		p.next()
+		return &firebaseRulesLiteralExpr{pos: pos, text: tok.text}, nil
+	case firebaseRulesTokenIdent:
+		p.next()
+		// raw and bytes string prefixes, e.g. r'\d+' or b'\xff'
+		if next := p.peek(); next != nil && next.kind == firebaseRulesTokenString && p.adjacent() && isFirebaseRulesStringPrefix(tok.text) {
+			p.next()
+			return &firebaseRulesLiteralExpr{pos: pos, text: tok.text + next.text}, nil
+		}
+		switch tok.text {
+		case "true", "false", "null":
+			return &firebaseRulesLiteralExpr{pos: pos, text: tok.text}, nil
+		}
+		return &firebaseRulesIdentExpr{pos: pos, name: tok.text}, nil
+	}
+
+	switch tok.text {
+	case "(":
+		p.next()
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &firebaseRulesParenExpr{pos: pos, x: x}, nil
+	case "[":
+		p.next()
+		elems, err := p.parseExprList("]")
+		if err != nil {
+			return nil, err
+		}
+		return &firebaseRulesListExpr{pos: pos, elems: elems}, nil
+	case "{":
+		p.next()
+		m := &firebaseRulesMapExpr{pos: pos}
+		for !p.accept("}") {
+			if len(m.keys) > 0 {
+				if _, err := p.expect(","); err != nil {
+					return nil, err
+				}
+				if p.accept("}") {
+					break
+				}
+			}
+			key, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			value, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			m.keys = append(m.keys, key)
+			m.values = append(m.values, value)
+		}
+		return m, nil
+	case "/":
+		path, err := p.parsePath(true)
+		if err != nil {
+			return nil, err
+		}
+		return &firebaseRulesPathExpr{path: path}, nil
+	}
+
+	return nil, p.unexpected("expression")
+}
+
+func isFirebaseRulesStringPrefix(s string) bool {
+	switch strings.ToLower(s) {
+	case "r", "b", "rb", "br":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package sidkik
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseFirebaseRules(t *testing.T) {
+	valid := map[string]string{
+		"firestore": `rules_version = '2';
+service cloud.firestore {
+  match /databases/{database}/documents {
+    function isSignedIn() {
+      return request.auth != null;
+    }
+
+    function isOwner(userId) {
+      let uid = request.auth.uid;
+      return isSignedIn() && uid == userId;
+    }
+
+    match /users/{userId} {
+      allow read: if isOwner(userId) || get(/databases/$(database)/documents/admins/$(request.auth.uid)).data.admin == true;
+      allow create, update: if isOwner(userId)
+        && request.resource.data.keys().hasOnly(['name', 'age'])
+        && request.resource.data.age is int
+        && request.resource.data.name.size() in [1, 2, 3]
+        && request.time < timestamp.date(2030, 1, 1);
+      allow delete: if false;
+    }
+
+    match /{document=**} {
+      allow read, write: if request.auth.token.roles[0] == 'admin' ? true : !(-1 > 0);
+    }
+  }
+}
+`,
+		"storage": `rules_version = '2';
+service firebase.storage {
+  match /b/{bucket}/o {
+    match /images/{imageId} {
+      allow read;
+      allow write: if request.resource.size < 5 * 1024 * 1024
+        && request.resource.contentType.matches(r'image/.*')
+        && {'a': 1, 'b': [2,]}.keys().size() == 2
+        && request.path[1:3] != null
+    }
+    match /user-files/{allPaths=**} {
+      allow read: if exists(/databases/(default)/documents/users/$(request.auth.uid))
+    }
+  }
+}
+`,
+		"functions only": `// shared helpers
+function isSignedIn() {
+  return request.auth != null;
+}
+`,
+		"empty": ``,
+	}
+	for name, src := range valid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseFirebaseRules(src); err != nil {
+				t.Errorf("parseFirebaseRules() error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_parseFirebaseRulesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "missing path",
+			src:  "service cloud.firestore {\n  match {\n  }\n}",
+			want: `2:9: expected "/", found "{"`,
+		},
+		{
+			name: "unknown method",
+			src:  "service cloud.firestore {\n  match /a {\n    allow reed: if true;\n  }\n}",
+			want: `3:11: unknown method "reed", expected one of read, write, get, list, create, update, delete`,
+		},
+		{
+			name: "missing if",
+			src:  "service cloud.firestore {\n  match /a {\n    allow read: true;\n  }\n}",
+			want: `3:17: expected "if", found "true"`,
+		},
+		{
+			name: "allow outside match",
+			src:  "service cloud.firestore {\n  allow read;\n}",
+			want: `2:3: allow must be inside a match block`,
+		},
+		{
+			name: "unbalanced braces",
+			src:  "service cloud.firestore {\n  match /a {\n    allow read: if true;\n  }\n",
+			want: `5:1: expected match, function or "}", found end of rules`,
+		},
+		{
+			name: "bad expression",
+			src:  "service cloud.firestore {\n  match /a {\n    allow read: if request.auth != ;\n  }\n}",
+			want: `3:36: expected expression, found ";"`,
+		},
+		{
+			name: "space in path",
+			src:  "service cloud.firestore {\n  match /a/ {b} {\n  }\n}",
+			want: `2:13: expected path segment after "/"`,
+		},
+		{
+			name: "missing return",
+			src:  "function f() {\n  request.auth != null;\n}",
+			want: `2:3: expected "return", found "request"`,
+		},
+		{
+			name: "unterminated string",
+			src:  "rules_version = '2;",
+			want: `1:17: unterminated string`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseFirebaseRules(tt.src)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("parseFirebaseRules() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseFirebaseRulesTree(t *testing.T) {
+	file, err := parseFirebaseRules("rules_version = '2';\nservice cloud.firestore {\n  match /databases/{database}/documents/{rest=**} {\n    allow read, list: if a || b && c;\n  }\n}")
+	if err != nil {
+		t.Fatalf("parseFirebaseRules() error = %v", err)
+	}
+
+	if file.version != "'2'" {
+		t.Errorf("version = %s, want '2'", file.version)
+	}
+	service := file.body[0].(*firebaseRulesService)
+	if service.name != "cloud.firestore" {
+		t.Errorf("service name = %s, want cloud.firestore", service.name)
+	}
+	match := service.body[0].(*firebaseRulesMatch)
+	wantSegments := []*firebaseRulesPathSegment{
+		{text: "databases"},
+		{wildcard: "database"},
+		{text: "documents"},
+		{wildcard: "rest", recursive: true},
+	}
+	if !reflect.DeepEqual(match.path.segments, wantSegments) {
+		t.Errorf("path segments = %v, want %v", match.path.segments, wantSegments)
+	}
+	allow := match.body[0].(*firebaseRulesAllow)
+	if !reflect.DeepEqual(allow.methods, []string{"read", "list"}) {
+		t.Errorf("methods = %v, want [read list]", allow.methods)
+	}
+	or, ok := allow.condition.(*firebaseRulesBinaryExpr)
+	if !ok || or.op != "||" {
+		t.Fatalf("condition = %#v, want a || expression", allow.condition)
+	}
+	if and, ok := or.y.(*firebaseRulesBinaryExpr); !ok || and.op != "&&" {
+		t.Errorf("condition rhs = %#v, want a && expression", or.y)
+	}
+	if allow.pos != (firebaseRulesPos{line: 4, column: 5}) {
+		t.Errorf("allow pos = %s, want 4:5", allow.pos)
+	}
+}
@@ -0,0 +1,394 @@
+package sidkik
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// firebaseRulesAnyArg matches any argument value in a function mock.
+const firebaseRulesAnyArg = "*"
+
+func firebaseRulesTestCaseSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		Description: `Test cases that must pass before the rule is released`,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: `Name of the test case, used when reporting failures`,
+				},
+				"expectation": {
+					Type:         schema.TypeString,
+					Required:     true,
+					ValidateFunc: validation.StringInSlice([]string{"ALLOW", "DENY"}, false),
+					Description:  `Expected outcome of the test case. One of ALLOW or DENY`,
+				},
+				"request": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					ValidateFunc: validation.StringIsJSON,
+					Description:  `JSON encoded request context, e.g. auth, method, path and time`,
+				},
+				"resource": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					ValidateFunc: validation.StringIsJSON,
+					Description:  `JSON encoded resource the request is made against`,
+				},
+				"path_encoding": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					ValidateFunc: validation.StringInSlice([]string{"ENCODING_UNSPECIFIED", "URL_ENCODED", "PLAIN"}, false),
+					Description:  `How request paths are encoded. One of ENCODING_UNSPECIFIED, URL_ENCODED or PLAIN`,
+				},
+				"function_mock": {
+					Type:        schema.TypeList,
+					Optional:    true,
+					Description: `Mocks for functions called by the rules, such as get() or exists()`,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"function": {
+								Type:        schema.TypeString,
+								Required:    true,
+								Description: `Name of the mocked function`,
+							},
+							"args": {
+								Type:        schema.TypeList,
+								Optional:    true,
+								Description: `JSON encoded arguments the mock matches. Use "*" to match any value`,
+								Elem: &schema.Schema{
+									Type: schema.TypeString,
+								},
+							},
+							"result": {
+								Type:         schema.TypeString,
+								Optional:     true,
+								ValidateFunc: validation.StringIsJSON,
+								Description:  `JSON encoded result of the mock. Leave unset for an undefined result`,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// runFirebaseRulesTestCases runs the resource's test cases against source and
+// returns an error describing every failing case.
+func runFirebaseRulesTestCases(d TerraformResourceData, config *Config, project, userAgent string, source map[string]interface{}) error {
+	testCases, ok := d.GetOk("test_case")
+	if !ok {
+		return nil
+	}
+
+	testSuite, err := expandFirebaseRulesTestSuite(testCases)
+	if err != nil {
+		return err
+	}
+
+	_, err = testFirebaseRulesCases(config, project, userAgent, source, testCases.([]interface{}), testSuite)
+	return err
+}
+
+// testFirebaseRulesCases runs the encoded testSuite of testCases against source
+// and returns the test results, or an error describing every failing case.
+func testFirebaseRulesCases(config *Config, project, userAgent string, source map[string]interface{}, testCases []interface{}, testSuite map[string]interface{}) ([]interface{}, error) {
+	log.Printf("[DEBUG] Running Firebase Rules test suite: %#v", testSuite)
+
+	res, err := testFirebaseRulesSource(config, project, userAgent, source, testSuite)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := firebaseRulesIssuesError(res["issues"]); err != nil {
+		return nil, err
+	}
+
+	if err := firebaseRulesTestResultsError(testCases, res["testResults"]); err != nil {
+		return nil, err
+	}
+
+	results, _ := res["testResults"].([]interface{})
+	return results, nil
+}
+
+func expandFirebaseRulesTestSuite(v interface{}) (map[string]interface{}, error) {
+	testCases := make([]interface{}, 0)
+	for i, raw := range v.([]interface{}) {
+		tc := raw.(map[string]interface{})
+		testCase := make(map[string]interface{})
+		testCase["expectation"] = tc["expectation"]
+
+		for _, field := range []string{"request", "resource"} {
+			if s, _ := tc[field].(string); s != "" {
+				value, err := structure.ExpandJsonFromString(s)
+				if err != nil {
+					return nil, fmt.Errorf("Error parsing %s of test case %d: %s", field, i, err)
+				}
+				testCase[field] = value
+			}
+		}
+
+		if s, _ := tc["path_encoding"].(string); s != "" {
+			testCase["pathEncoding"] = s
+		}
+
+		mocks, err := expandFirebaseRulesFunctionMocks(tc["function_mock"])
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing function_mock of test case %d: %s", i, err)
+		}
+		if len(mocks) > 0 {
+			testCase["functionMocks"] = mocks
+		}
+
+		testCases = append(testCases, testCase)
+	}
+
+	return map[string]interface{}{
+		"testCases": testCases,
+	}, nil
+}
+
+func expandFirebaseRulesFunctionMocks(v interface{}) ([]interface{}, error) {
+	l, _ := v.([]interface{})
+	mocks := make([]interface{}, 0, len(l))
+	for _, raw := range l {
+		m := raw.(map[string]interface{})
+		mock := make(map[string]interface{})
+		mock["function"] = m["function"]
+
+		args := make([]interface{}, 0)
+		for _, rawArg := range m["args"].([]interface{}) {
+			arg, _ := rawArg.(string)
+			if arg == firebaseRulesAnyArg {
+				args = append(args, map[string]interface{}{"anyValue": map[string]interface{}{}})
+				continue
+			}
+			var value interface{}
+			if err := json.Unmarshal([]byte(arg), &value); err != nil {
+				return nil, fmt.Errorf("invalid argument %q: %s", arg, err)
+			}
+			args = append(args, map[string]interface{}{"exactValue": value})
+		}
+		mock["args"] = args
+
+		if result, _ := m["result"].(string); result != "" {
+			var value interface{}
+			if err := json.Unmarshal([]byte(result), &value); err != nil {
+				return nil, fmt.Errorf("invalid result %q: %s", result, err)
+			}
+			mock["result"] = map[string]interface{}{"value": value}
+		} else {
+			mock["result"] = map[string]interface{}{"undefined": map[string]interface{}{}}
+		}
+
+		mocks = append(mocks, mock)
+	}
+	return mocks, nil
+}
+
+// firebaseRulesTestResultsError returns an error naming each failed test case
+// together with the expressions the rules engine evaluated for it.
+func firebaseRulesTestResultsError(testCases []interface{}, v interface{}) error {
+	results, _ := v.([]interface{})
+
+	var failures []string
+	for i, raw := range results {
+		result := raw.(map[string]interface{})
+		if result["state"] == "SUCCESS" {
+			continue
+		}
+
+		name := fmt.Sprintf("#%d", i)
+		expectation := ""
+		if i < len(testCases) {
+			tc := testCases[i].(map[string]interface{})
+			if n, _ := tc["name"].(string); n != "" {
+				name = fmt.Sprintf("%q (#%d)", n, i)
+			}
+			expectation, _ = tc["expectation"].(string)
+		}
+
+		lines := []string{fmt.Sprintf("test case %s: expected %s", name, expectation)}
+		if pos, ok := result["errorPosition"]; ok {
+			lines = append(lines, fmt.Sprintf("  error at %s", flattenFirebaseRulesSourcePosition(pos)))
+		}
+		if msgs, ok := result["debugMessages"].([]interface{}); ok {
+			for _, msg := range msgs {
+				lines = append(lines, fmt.Sprintf("  %v", msg))
+			}
+		}
+		if exprs, ok := result["visitedExpressions"].([]interface{}); ok {
+			for _, rawExpr := range exprs {
+				expr := rawExpr.(map[string]interface{})
+				value, _ := json.Marshal(expr["value"])
+				lines = append(lines, fmt.Sprintf("  evaluated %s = %s", flattenFirebaseRulesSourcePosition(expr["sourcePosition"]), value))
+			}
+		}
+		failures = append(failures, strings.Join(lines, "\n"))
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("Error testing Firebase Rules: %d of %d test cases failed:\n%s", len(failures), len(results), strings.Join(failures, "\n"))
+}
+
+// firebaseRulesAllowCondition is the source range of the condition of an allow
+// statement, from the first to the last position of its sub-expressions.
+type firebaseRulesAllowCondition struct {
+	file  string
+	start firebaseRulesPos
+	end   firebaseRulesPos
+}
+
+func (c firebaseRulesAllowCondition) String() string {
+	return fmt.Sprintf("%s:%s", c.file, c.start)
+}
+
+// contains reports whether pos lies within the condition. The rest of the line
+// of the last sub-expression belongs to the condition as well, as the API may
+// report the position of an operator or member name.
+func (c firebaseRulesAllowCondition) contains(file string, pos firebaseRulesPos) bool {
+	if file != c.file || firebaseRulesPosBefore(pos, c.start) {
+		return false
+	}
+	return !firebaseRulesPosBefore(c.end, pos) || pos.line == c.end.line
+}
+
+func firebaseRulesPosBefore(a, b firebaseRulesPos) bool {
+	return a.line < b.line || (a.line == b.line && a.column < b.column)
+}
+
+// firebaseRulesAllowConditions returns the conditions of the allow statements
+// in files in source order. An allow without a condition has nothing to
+// exercise and is skipped.
+func firebaseRulesAllowConditions(files []interface{}) ([]firebaseRulesAllowCondition, error) {
+	conditions := make([]firebaseRulesAllowCondition, 0)
+	for _, raw := range files {
+		file := raw.(map[string]interface{})
+		name := fmt.Sprintf("%v", file["name"])
+		content, _ := file["content"].(string)
+
+		rules, err := parseFirebaseRules(content)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing %s: %s", name, err)
+		}
+		conditions = appendFirebaseRulesAllowConditions(conditions, name, rules.body)
+	}
+	return conditions, nil
+}
+
+func appendFirebaseRulesAllowConditions(conditions []firebaseRulesAllowCondition, file string, body []interface{}) []firebaseRulesAllowCondition {
+	for _, stmt := range body {
+		switch s := stmt.(type) {
+		case *firebaseRulesService:
+			conditions = appendFirebaseRulesAllowConditions(conditions, file, s.body)
+		case *firebaseRulesMatch:
+			conditions = appendFirebaseRulesAllowConditions(conditions, file, s.body)
+		case *firebaseRulesAllow:
+			if s.condition == nil {
+				continue
+			}
+			condition := firebaseRulesAllowCondition{file: file, start: s.condition.position(), end: s.condition.position()}
+			inspectFirebaseRulesExpr(s.condition, func(e firebaseRulesExpr) bool {
+				if pos := e.position(); firebaseRulesPosBefore(pos, condition.start) {
+					condition.start = pos
+				} else if firebaseRulesPosBefore(condition.end, pos) {
+					condition.end = pos
+				}
+				return true
+			})
+			conditions = append(conditions, condition)
+		}
+	}
+	return conditions
+}
+
+// firebaseRulesVisitedPosition is the position of an expression the rules
+// engine evaluated during a test.
+type firebaseRulesVisitedPosition struct {
+	file string
+	pos  firebaseRulesPos
+}
+
+// flattenFirebaseRulesVisitedPositions returns the positions of the visited
+// expressions and expression reports of the test results.
+func flattenFirebaseRulesVisitedPositions(results []interface{}) []firebaseRulesVisitedPosition {
+	visited := make([]firebaseRulesVisitedPosition, 0)
+	var appendReports func(v interface{})
+	appendReports = func(v interface{}) {
+		reports, _ := v.([]interface{})
+		for _, raw := range reports {
+			report := raw.(map[string]interface{})
+			if pos, ok := expandFirebaseRulesVisitedPosition(report["sourcePosition"]); ok {
+				visited = append(visited, pos)
+			}
+			appendReports(report["children"])
+		}
+	}
+
+	for _, raw := range results {
+		result := raw.(map[string]interface{})
+		appendReports(result["visitedExpressions"])
+		appendReports(result["expressionReports"])
+	}
+	return visited
+}
+
+func expandFirebaseRulesVisitedPosition(v interface{}) (firebaseRulesVisitedPosition, bool) {
+	pos, ok := v.(map[string]interface{})
+	if !ok {
+		return firebaseRulesVisitedPosition{}, false
+	}
+	line, err := strconv.Atoi(fmt.Sprintf("%v", pos["line"]))
+	if err != nil {
+		return firebaseRulesVisitedPosition{}, false
+	}
+	column, err := strconv.Atoi(fmt.Sprintf("%v", pos["column"]))
+	if err != nil {
+		return firebaseRulesVisitedPosition{}, false
+	}
+	file, _ := pos["fileName"].(string)
+	return firebaseRulesVisitedPosition{file: file, pos: firebaseRulesPos{line: line, column: column}}, true
+}
+
+// firebaseRulesCoverage splits conditions into those containing a visited
+// position and those that were never exercised, and returns the percentage
+// of covered conditions. Without any conditions the coverage is 100.
+func firebaseRulesCoverage(conditions []firebaseRulesAllowCondition, visited []firebaseRulesVisitedPosition) (float64, []firebaseRulesAllowCondition, []firebaseRulesAllowCondition) {
+	covered := make([]firebaseRulesAllowCondition, 0, len(conditions))
+	uncovered := make([]firebaseRulesAllowCondition, 0)
+	for _, condition := range conditions {
+		hit := false
+		for _, v := range visited {
+			if condition.contains(v.file, v.pos) {
+				hit = true
+				break
+			}
+		}
+		if hit {
+			covered = append(covered, condition)
+		} else {
+			uncovered = append(uncovered, condition)
+		}
+	}
+
+	if len(conditions) == 0 {
+		return 100, covered, uncovered
+	}
+	percentage := math.Round(10000*float64(len(covered))/float64(len(conditions))) / 100
+	return percentage, covered, uncovered
+}
@@ -0,0 +1,190 @@
+package sidkik
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_expandFirebaseRulesTestSuite(t *testing.T) {
+	testCases := []interface{}{
+		map[string]interface{}{
+			"name":          "owner can read",
+			"expectation":   "ALLOW",
+			"request":       `{"auth":{"uid":"alice"},"method":"get","path":"/databases/(default)/documents/users/alice"}`,
+			"resource":      "",
+			"path_encoding": "",
+			"function_mock": []interface{}{
+				map[string]interface{}{
+					"function": "get",
+					"args":     []interface{}{`"/databases/(default)/documents/users/alice"`, "*"},
+					"result":   `{"data":{"owner":"alice"}}`,
+				},
+				map[string]interface{}{
+					"function": "exists",
+					"args":     []interface{}{"*"},
+					"result":   "",
+				},
+			},
+		},
+	}
+
+	want := map[string]interface{}{
+		"testCases": []interface{}{
+			map[string]interface{}{
+				"expectation": "ALLOW",
+				"request": map[string]interface{}{
+					"auth":   map[string]interface{}{"uid": "alice"},
+					"method": "get",
+					"path":   "/databases/(default)/documents/users/alice",
+				},
+				"functionMocks": []interface{}{
+					map[string]interface{}{
+						"function": "get",
+						"args": []interface{}{
+							map[string]interface{}{"exactValue": "/databases/(default)/documents/users/alice"},
+							map[string]interface{}{"anyValue": map[string]interface{}{}},
+						},
+						"result": map[string]interface{}{
+							"value": map[string]interface{}{"data": map[string]interface{}{"owner": "alice"}},
+						},
+					},
+					map[string]interface{}{
+						"function": "exists",
+						"args": []interface{}{
+							map[string]interface{}{"anyValue": map[string]interface{}{}},
+						},
+						"result": map[string]interface{}{"undefined": map[string]interface{}{}},
+					},
+				},
+			},
+		},
+	}
+
+	got, err := expandFirebaseRulesTestSuite(testCases)
+	if err != nil {
+		t.Fatalf("expandFirebaseRulesTestSuite() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandFirebaseRulesTestSuite() = %#v, want %#v", got, want)
+	}
+}
+
+func Test_firebaseRulesTestResultsError(t *testing.T) {
+	testCases := []interface{}{
+		map[string]interface{}{"name": "anyone can read", "expectation": "ALLOW"},
+		map[string]interface{}{"name": "", "expectation": "DENY"},
+	}
+
+	var result map[string]interface{}
+	response := `{
+		"testResults": [
+			{
+				"state": "SUCCESS"
+			},
+			{
+				"state": "FAILURE",
+				"debugMessages": ["Unexpected allow"],
+				"errorPosition": {"fileName": "firestore.rules", "line": 6, "column": 21},
+				"visitedExpressions": [
+					{
+						"sourcePosition": {"fileName": "firestore.rules", "line": 6, "column": 28},
+						"value": true
+					}
+				]
+			}
+		]
+	}`
+	if err := json.Unmarshal([]byte(response), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	err := firebaseRulesTestResultsError(testCases, result["testResults"])
+	if err == nil {
+		t.Fatal("firebaseRulesTestResultsError() expected an error")
+	}
+	for _, want := range []string{
+		"1 of 2 test cases failed",
+		"test case #1: expected DENY",
+		"error at firestore.rules:6:21",
+		"Unexpected allow",
+		"evaluated firestore.rules:6:28 = true",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("firebaseRulesTestResultsError() = %q, missing %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "anyone can read") {
+		t.Errorf("firebaseRulesTestResultsError() = %q, should not report passing test cases", err)
+	}
+}
+
+func Test_firebaseRulesCoverage(t *testing.T) {
+	files := []interface{}{
+		map[string]interface{}{
+			"name": "firestore.rules",
+			"content": `rules_version = '2';
+service cloud.firestore {
+  match /databases/{database}/documents {
+    match /users/{userId} {
+      allow read;
+      allow get: if request.auth != null
+        && request.auth.uid == userId;
+      allow write: if false;
+    }
+  }
+}
+`,
+		},
+	}
+
+	conditions, err := firebaseRulesAllowConditions(files)
+	if err != nil {
+		t.Fatalf("firebaseRulesAllowConditions() error = %v", err)
+	}
+	if len(conditions) != 2 {
+		t.Fatalf("firebaseRulesAllowConditions() = %v, want 2 conditions", conditions)
+	}
+
+	var result map[string]interface{}
+	response := `{
+		"testResults": [
+			{
+				"state": "SUCCESS",
+				"expressionReports": [
+					{
+						"sourcePosition": {"fileName": "firestore.rules", "line": 7, "column": 30},
+						"children": [
+							{"sourcePosition": {"fileName": "firestore.rules", "line": 7, "column": 12}}
+						]
+					}
+				]
+			},
+			{
+				"state": "SUCCESS",
+				"visitedExpressions": [
+					{"sourcePosition": {"fileName": "other.rules", "line": 8, "column": 23}}
+				]
+			}
+		]
+	}`
+	if err := json.Unmarshal([]byte(response), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	coverage, covered, uncovered := firebaseRulesCoverage(conditions, flattenFirebaseRulesVisitedPositions(result["testResults"].([]interface{})))
+	if coverage != 50 {
+		t.Errorf("firebaseRulesCoverage() coverage = %v, want 50", coverage)
+	}
+	if len(covered) != 1 || covered[0].String() != "firestore.rules:6:21" {
+		t.Errorf("firebaseRulesCoverage() covered = %v, want [firestore.rules:6:21]", covered)
+	}
+	if len(uncovered) != 1 || uncovered[0].String() != "firestore.rules:8:23" {
+		t.Errorf("firebaseRulesCoverage() uncovered = %v, want [firestore.rules:8:23]", uncovered)
+	}
+
+	if coverage, _, _ := firebaseRulesCoverage(nil, nil); coverage != 100 {
+		t.Errorf("firebaseRulesCoverage() without conditions = %v, want 100", coverage)
+	}
+}
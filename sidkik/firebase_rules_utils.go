@@ -0,0 +1,760 @@
+package sidkik
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// createFirebaseRuleset creates a new ruleset for the release named by
+// releaseTmpl from the resource's rule source and returns the name of the
+// created ruleset. The resource's test cases are run against the source first
+// and no ruleset is created if any of them fail.
+func createFirebaseRuleset(d *schema.ResourceData, meta interface{}, ruleType, releaseTmpl string, timeout time.Duration) (string, error) {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return "", err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return "", fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	}
+
+	files, err := firebaseRulesSourceFiles(d.Get("rule"), d.Get("rule_file"), d.Get("files"), ruleType)
+	if err != nil {
+		return "", err
+	}
+
+	obj := make(map[string]interface{})
+	obj["ruleType"] = ruleType
+	obj["files"] = files
+
+	obj, err = resourceFirebaseRuleEncoder(d, meta, obj)
+	if err != nil {
+		return "", err
+	}
+
+	if err := runFirebaseRulesTestCases(d, config, project, userAgent, obj["source"].(map[string]interface{})); err != nil {
+		return "", err
+	}
+
+	if err := markFirebaseRulesetRelease(d, config, obj, releaseTmpl); err != nil {
+		return "", err
+	}
+
+	return insertFirebaseRuleset(d, meta, obj, timeout)
+}
+
+// firebaseRulesReleaseMarker starts the comment appended to the last source
+// file of a ruleset created for a release, followed by the release id, e.g.
+// cloud.firestore/orders. Rulesets do not record the release they were created
+// for otherwise. The comment changes neither the rules nor source_sha256, and
+// is stripped when the source is read back.
+const firebaseRulesReleaseMarker = "// sidkik:release "
+
+// markFirebaseRulesetRelease appends the release marker for the release named
+// by releaseTmpl to the encoded ruleset obj.
+func markFirebaseRulesetRelease(d TerraformResourceData, config *Config, obj map[string]interface{}, releaseTmpl string) error {
+	releaseName, err := replaceVars(d, config, releaseTmpl)
+	if err != nil {
+		return err
+	}
+
+	source, _ := obj["source"].(map[string]interface{})
+	files, _ := source["files"].([]interface{})
+	if len(files) == 0 {
+		return nil
+	}
+	last := files[len(files)-1].(map[string]interface{})
+	content, _ := last["content"].(string)
+	last["content"] = content + "\n" + firebaseRulesReleaseMarker + firebaseRulesReleaseId(releaseName) + "\n"
+	return nil
+}
+
+// unmarkFirebaseRulesContent strips the release marker from the content of a
+// source file, returning the release id it named.
+func unmarkFirebaseRulesContent(content string) (string, string) {
+	i := strings.LastIndex(content, "\n"+firebaseRulesReleaseMarker)
+	if i < 0 || !strings.HasSuffix(content, "\n") {
+		return content, ""
+	}
+	release := strings.TrimSuffix(content[i+1+len(firebaseRulesReleaseMarker):], "\n")
+	if release == "" || strings.ContainsAny(release, "\n ") {
+		return content, ""
+	}
+	return content[:i], release
+}
+
+// firebaseRulesetRelease returns the id of the release a ruleset source was
+// created for, or an empty string if it carries no release marker.
+func firebaseRulesetRelease(source interface{}) string {
+	src, _ := source.(map[string]interface{})
+	files, _ := src["files"].([]interface{})
+	if len(files) == 0 {
+		return ""
+	}
+	last, _ := files[len(files)-1].(map[string]interface{})
+	content, _ := last["content"].(string)
+	_, release := unmarkFirebaseRulesContent(content)
+	return release
+}
+
+// insertFirebaseRuleset creates a ruleset from an encoded ruleset object and
+// returns the name of the created ruleset.
+func insertFirebaseRuleset(d *schema.ResourceData, meta interface{}, obj map[string]interface{}, timeout time.Duration) (string, error) {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return "", err
+	}
+	url, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}projects/{{project}}/rulesets")
+	if err != nil {
+		return "", err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return "", fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	}
+
+	log.Printf("[DEBUG] Creating new Firebase Rule: %#v", obj)
+
+	res, err := sendRequestWithTimeout(config, "POST", project, url, userAgent, obj, timeout)
+	if err != nil {
+		return "", fmt.Errorf("Error creating Firebase Rule: %s", err)
+	}
+
+	log.Printf("[DEBUG] Finished creating Firebase Rule %q: %#v", res["name"], res)
+
+	return res["name"].(string), nil
+}
+
+// releaseFirebaseRuleset points the release named by releaseTmpl at rulesetName.
+// The release is created if it does not exist yet.
+func releaseFirebaseRuleset(d *schema.ResourceData, meta interface{}, releaseTmpl, rulesetName string, timeout time.Duration) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	}
+
+	releaseName, err := replaceVars(d, config, releaseTmpl)
+	if err != nil {
+		return err
+	}
+
+	urlRelease, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}"+releaseName)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Updating release %q with Firebase Rule %q", releaseName, rulesetName)
+
+	releaseObj := make(map[string]interface{})
+	releaseObj["name"] = releaseName
+	releaseObj["ruleName"] = rulesetName
+
+	releaseObj, err = resourceFirebaseReleasePatchEncoder(d, meta, releaseObj)
+	if err != nil {
+		log.Printf("[ERROR] Cannot convert release to encoded obj: %v", err)
+		return err
+	}
+
+	log.Printf("[DEBUG] release obj: %v", releaseObj)
+	_, err = sendRequestWithTimeout(config, "PATCH", project, urlRelease, userAgent, releaseObj, timeout)
+	if err == nil {
+		log.Printf("[DEBUG] Finished release %q with Firebase Rule %q", releaseName, rulesetName)
+		return nil
+	}
+	if !isGoogleApiErrorWithCode(err, 404) {
+		return fmt.Errorf("Error updating Firebase Release: %s", err)
+	}
+
+	// release doesn't exist - try to create
+	urlCreateRelease, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}projects/{{project}}/releases")
+	if err != nil {
+		return err
+	}
+	releasePostObj := make(map[string]interface{})
+	releasePostObj["name"] = releaseName
+	releasePostObj["ruleName"] = rulesetName
+	releasePostObj, err = resourceFirebaseReleasePostEncoder(d, meta, releasePostObj)
+	if err != nil {
+		log.Printf("[ERROR] Cannot convert release to encoded obj: %v", err)
+		return err
+	}
+
+	_, err = sendRequestWithTimeout(config, "POST", project, urlCreateRelease, userAgent, releasePostObj, timeout)
+	if err != nil {
+		return fmt.Errorf("Error creating Firebase Release: %s", err)
+	}
+
+	log.Printf("[DEBUG] Finished creating release %q with Firebase Rule %q", releaseName, rulesetName)
+	return nil
+}
+
+// getFirebaseReleaseRulesetName returns the ruleset currently released under
+// the release named by releaseTmpl, or an empty string if there is no release.
+func getFirebaseReleaseRulesetName(d *schema.ResourceData, meta interface{}, releaseTmpl string) (string, error) {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return "", err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return "", fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	}
+
+	url, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}"+releaseTmpl)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := sendRequest(config, "GET", project, url, userAgent, nil)
+	if err != nil {
+		if isGoogleApiErrorWithCode(err, 404) {
+			return "", nil
+		}
+		return "", fmt.Errorf("Error reading Firebase Release: %s", err)
+	}
+
+	rulesetName, _ := res["rulesetName"].(string)
+	return rulesetName, nil
+}
+
+func firebaseRulesOnDestroySchema() *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Default:      "keep",
+		ValidateFunc: validation.StringInSlice([]string{"keep", "restore_previous", "lock_down"}, false),
+		Description:  `What happens to the release on destroy. keep leaves the rule live, restore_previous releases the ruleset that was live before this resource was created and lock_down releases a ruleset that denies all access. restore_previous leaves the rule live if the previous ruleset was deleted in the meantime, e.g. outside of Terraform, or is unknown because the resource was imported`,
+	}
+}
+
+// destroyFirebaseRulesRelease applies the resource's on_destroy behavior to the
+// release named by releaseTmpl. lockDownRule is released for lock_down.
+func destroyFirebaseRulesRelease(d *schema.ResourceData, meta interface{}, releaseTmpl, ruleType, lockDownRule string) error {
+	config := meta.(*Config)
+	timeout := d.Timeout(schema.TimeoutDelete)
+
+	switch d.Get("on_destroy").(string) {
+	case "restore_previous":
+		userAgent, err := generateUserAgentString(d, config.userAgent)
+		if err != nil {
+			return err
+		}
+		project, err := getProject(d, config)
+		if err != nil {
+			return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+		}
+
+		previous := d.Get("previous_ruleset_name").(string)
+		previousExists := false
+		if previous != "" {
+			// the ruleset may have been deleted since, e.g. outside of Terraform
+			_, err := sendRequestWithTimeout(config, "GET", project, config.FirebaseRulesBasePath+previous, userAgent, nil, timeout)
+			if err != nil && !isGoogleApiErrorWithCode(err, 404) {
+				return fmt.Errorf("Error reading previous Firebase Ruleset %q: %s", previous, err)
+			}
+			previousExists = err == nil
+		}
+		createdRelease, _ := d.Get("created_release").(bool)
+
+		switch firebaseRulesRestorePreviousAction(previous, previousExists, createdRelease) {
+		case "release":
+			log.Printf("[INFO] Restoring previous Firebase ruleset %q", previous)
+			return releaseFirebaseRuleset(d, meta, releaseTmpl, previous, timeout)
+		case "delete":
+			// nothing was released before this resource, removing the release
+			// leaves the service without rules and so denies all access
+			url, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}"+releaseTmpl)
+			if err != nil {
+				return err
+			}
+
+			log.Printf("[INFO] No previous Firebase ruleset, deleting release %q", url)
+			_, err = sendRequestWithTimeout(config, "DELETE", project, url, userAgent, nil, timeout)
+			if err != nil && !isGoogleApiErrorWithCode(err, 404) {
+				return fmt.Errorf("Error deleting Firebase Release: %s", err)
+			}
+			return nil
+		default:
+			if previous != "" {
+				log.Printf("[WARN] Previous Firebase ruleset %q no longer exists, leaving the current release in place", previous)
+			} else {
+				log.Printf("[WARN] The ruleset released before this resource is unknown, e.g. because it was imported, leaving the current release in place")
+			}
+			return nil
+		}
+	case "lock_down":
+		obj := map[string]interface{}{
+			"rule":     lockDownRule,
+			"ruleType": ruleType,
+		}
+		obj, err := resourceFirebaseRuleEncoder(d, meta, obj)
+		if err != nil {
+			return err
+		}
+		if err := markFirebaseRulesetRelease(d, config, obj, releaseTmpl); err != nil {
+			return err
+		}
+		rulesetName, err := insertFirebaseRuleset(d, meta, obj, timeout)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("[INFO] Locking down Firebase release with ruleset %q", rulesetName)
+		return releaseFirebaseRuleset(d, meta, releaseTmpl, rulesetName, timeout)
+	default:
+		log.Printf("[INFO] Not deleting rule - new rule had been made the latest release")
+		return nil
+	}
+}
+
+// firebaseRulesRestorePreviousAction returns what on_destroy = "restore_previous"
+// does: "release" the previous ruleset again, "delete" the release when the
+// resource created it, or "keep" the current release when neither is possible.
+// A release the resource did not create is never deleted, since the ruleset it
+// held before may simply be unknown, e.g. after import.
+func firebaseRulesRestorePreviousAction(previous string, previousExists, createdRelease bool) string {
+	switch {
+	case previous != "" && previousExists:
+		return "release"
+	case previous == "" && createdRelease:
+		return "delete"
+	}
+	return "keep"
+}
+
+func firebaseRulesCreatedReleaseSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeBool,
+		Computed:    true,
+		Description: `Whether this resource created the release because no ruleset was released before it. on_destroy = "restore_previous" only deletes the release in that case`,
+	}
+}
+
+func firebaseRulesRetainRulesetsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeInt,
+		Optional:     true,
+		ValidateFunc: validation.IntAtLeast(1),
+		Description:  `Number of most recent rulesets created for this resource's release to keep after a release. Older ones are deleted unless a release references them. Rulesets without a release marker, e.g. those of sidkik_firebase_ruleset, are never deleted. Overrides the provider setting`,
+	}
+}
+
+// deleteExpiredFirebaseRulesets deletes the unreleased rulesets created for the
+// release named by releaseTmpl that fall outside the configured retention.
+// Rulesets are matched by their release marker, so rulesets created for the
+// release before retention was enabled or the resource was imported are
+// included, while rulesets without a marker are never touched. Failures are
+// logged rather than returned because the new ruleset has already been
+// released.
+func deleteExpiredFirebaseRulesets(d TerraformResourceData, config *Config, releaseTmpl, rulesetName string, timeout time.Duration) {
+	retain := config.RetainRulesets
+	if v, ok := d.GetOk("retain_rulesets"); ok {
+		retain = v.(int)
+	}
+	if retain <= 0 {
+		return
+	}
+
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		log.Printf("[WARN] Skipping ruleset retention: %s", err)
+		return
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		log.Printf("[WARN] Skipping ruleset retention: %s", err)
+		return
+	}
+
+	releaseName, err := replaceVars(d, config, releaseTmpl)
+	if err != nil {
+		log.Printf("[WARN] Skipping ruleset retention: %s", err)
+		return
+	}
+
+	releases, err := listFirebaseRules(d, config, userAgent, "releases")
+	if err != nil {
+		log.Printf("[WARN] Skipping ruleset retention, unable to list releases: %s", err)
+		return
+	}
+
+	rulesets, err := listFirebaseRules(d, config, userAgent, "rulesets")
+	if err != nil {
+		log.Printf("[WARN] Skipping ruleset retention, unable to list rulesets: %s", err)
+		return
+	}
+
+	// keep the ruleset that on_destroy = "restore_previous" releases again
+	var protected []string
+	if d.Get("on_destroy") == "restore_previous" {
+		if previous, ok := d.GetOk("previous_ruleset_name"); ok {
+			protected = append(protected, previous.(string))
+		}
+	}
+
+	// the list omits the source, so it is read one ruleset at a time
+	sourceOf := func(name string) (interface{}, error) {
+		res, err := sendRequestWithTimeout(config, "GET", project, config.FirebaseRulesBasePath+name, userAgent, nil, timeout)
+		if err != nil {
+			return nil, err
+		}
+		return res["source"], nil
+	}
+
+	expired, err := expiredFirebaseRulesets(releases, rulesets, protected, releaseName, rulesetName, retain, sourceOf)
+	if err != nil {
+		log.Printf("[WARN] Skipping ruleset retention, unable to read rulesets: %s", err)
+		return
+	}
+
+	for _, name := range expired {
+		log.Printf("[DEBUG] Deleting expired Firebase ruleset %q", name)
+		url := config.FirebaseRulesBasePath + name
+		if _, err := sendRequestWithTimeout(config, "DELETE", project, url, userAgent, nil, timeout); err != nil {
+			log.Printf("[WARN] Unable to delete expired Firebase ruleset %q: %s", name, err)
+		}
+	}
+}
+
+// expiredFirebaseRulesets returns the names of the rulesets created for
+// releaseName, newest first, that are older than the newest retain of them,
+// counting the just released rulesetName, and that neither a release nor the
+// protected list references.
+func expiredFirebaseRulesets(releases, rulesets []interface{}, protected []string, releaseName, rulesetName string, retain int, sourceOf func(string) (interface{}, error)) ([]string, error) {
+	released := golangSetFromStringSlice(protected)
+	for _, raw := range releases {
+		if name, ok := raw.(map[string]interface{})["rulesetName"].(string); ok {
+			released[name] = struct{}{}
+		}
+	}
+
+	releaseId := firebaseRulesReleaseId(releaseName)
+	service := firebaseRulesReleaseService(releaseId)
+
+	kept := 0
+	expired := []string{}
+	for _, raw := range filterFirebaseRulesByCreateTime(rulesets, "", "") {
+		ruleset := raw.(map[string]interface{})
+		name, _ := ruleset["name"].(string)
+		if name == rulesetName {
+			kept++
+			continue
+		}
+		if _, ok := released[name]; ok || !firebaseRulesetHasService(ruleset, service) {
+			continue
+		}
+
+		source, err := sourceOf(name)
+		if err != nil {
+			return nil, err
+		}
+		if firebaseRulesetRelease(source) != releaseId {
+			continue
+		}
+
+		if kept < retain {
+			kept++
+			continue
+		}
+		expired = append(expired, name)
+	}
+	return expired, nil
+}
+
+func firebaseRulesetHasService(ruleset map[string]interface{}, service string) bool {
+	metadata, _ := ruleset["metadata"].(map[string]interface{})
+	services, _ := metadata["services"].([]interface{})
+	for _, s := range services {
+		if s == service {
+			return true
+		}
+	}
+	return false
+}
+
+// listFirebaseRules lists every item of the given collection, either releases
+// or rulesets, across all pages.
+func listFirebaseRules(d TerraformResourceData, config *Config, userAgent, collection string) ([]interface{}, error) {
+	url, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}projects/{{project}}/"+collection)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return paginatedListRequest(project, url, userAgent, config, func(res map[string]interface{}) []interface{} {
+		l, _ := res[collection].([]interface{})
+		return l
+	})
+}
+
+// getFirebaseDefaultStorageBucket looks up the project's default storage bucket,
+// e.g. <project>.appspot.com or <project>.firebasestorage.app.
+func getFirebaseDefaultStorageBucket(d TerraformResourceData, config *Config, userAgent string) (string, error) {
+	url, err := replaceVars(d, config, "{{FirebaseBasePath}}projects/{{project}}/adminSdkConfig")
+	if err != nil {
+		return "", err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return "", fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	}
+
+	res, err := sendRequest(config, "GET", project, url, userAgent, nil)
+	if err != nil {
+		return "", fmt.Errorf("Error fetching default storage bucket for project %q: %s", project, err)
+	}
+
+	bucket, ok := res["storageBucket"].(string)
+	if !ok || bucket == "" {
+		return "", fmt.Errorf("Error fetching default storage bucket for project %q: project has no default storage bucket", project)
+	}
+
+	return bucket, nil
+}
+
+// firebaseRulesetNameCustomizeDiff marks the ruleset name as unknown when the
+// rule source changes, since an update always produces a new ruleset.
+func firebaseRulesetNameCustomizeDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !firebaseRulesSourceHasChange(d) {
+		return nil
+	}
+	return d.SetNewComputed("name")
+}
+
+// firebaseRulesCompileCustomizeDiff sends changed rule source to the Firebase
+// Rules test API during plan so that compile errors fail the plan instead of
+// the apply.
+func firebaseRulesCompileCustomizeDiff(ruleType string) schema.CustomizeDiffFunc {
+	return func(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		if !firebaseRulesSourceHasChange(d) || !firebaseRulesSourceKnown(d) {
+			return nil
+		}
+		files, err := firebaseRulesSourceFiles(d.Get("rule"), d.Get("rule_file"), d.Get("files"), ruleType)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return nil
+		}
+
+		config := meta.(*Config)
+		project, err := getProjectFromDiff(d, config)
+		if err != nil {
+			// the project may not be known until apply
+			log.Printf("[DEBUG] Skipping rules compilation check: %s", err)
+			return nil
+		}
+
+		source := map[string]interface{}{
+			"files": files,
+		}
+
+		res, err := testFirebaseRulesSource(config, project, config.userAgent, source, nil)
+		if err != nil {
+			return err
+		}
+
+		return firebaseRulesIssuesError(res["issues"])
+	}
+}
+
+// testFirebaseRulesSource runs the Firebase Rules test API against source. When
+// testSuite is nil only the compilation issues are returned.
+func testFirebaseRulesSource(config *Config, project, userAgent string, source, testSuite map[string]interface{}) (map[string]interface{}, error) {
+	url := fmt.Sprintf("%sprojects/%s:test", config.FirebaseRulesBasePath, project)
+
+	obj := make(map[string]interface{})
+	obj["source"] = source
+	if testSuite != nil {
+		obj["testSuite"] = testSuite
+	}
+
+	res, err := sendRequest(config, "POST", project, url, userAgent, obj)
+	if err != nil {
+		return nil, fmt.Errorf("Error testing Firebase Rules: %s", err)
+	}
+
+	return res, nil
+}
+
+// firebaseRulesIssuesError converts the issues returned by the Firebase Rules
+// API into an error naming the file, line and column of every compile error.
+// Warnings and deprecations are only logged.
+func firebaseRulesIssuesError(v interface{}) error {
+	issues, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var errs []string
+	for _, raw := range issues {
+		issue := raw.(map[string]interface{})
+		msg := fmt.Sprintf("%s: %v", flattenFirebaseRulesSourcePosition(issue["sourcePosition"]), issue["description"])
+		if issue["severity"] != "ERROR" {
+			log.Printf("[WARN] Firebase Rules %v: %s", issue["severity"], msg)
+			continue
+		}
+		errs = append(errs, msg)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("Error compiling Firebase Rules:\n%s", strings.Join(errs, "\n"))
+}
+
+// flattenFirebaseRulesSourcePosition renders a SourcePosition as file:line:column.
+func flattenFirebaseRulesSourcePosition(v interface{}) string {
+	pos, ok := v.(map[string]interface{})
+	if !ok {
+		return "<unknown>"
+	}
+	return fmt.Sprintf("%v:%v:%v", pos["fileName"], pos["line"], pos["column"])
+}
+
+// filterFirebaseRulesByCreateTime returns the releases or rulesets created
+// within [after, before), newest first. Empty bounds are ignored.
+func filterFirebaseRulesByCreateTime(items []interface{}, after, before string) []interface{} {
+	afterTime, _ := time.Parse(time.RFC3339, after)
+	beforeTime, _ := time.Parse(time.RFC3339, before)
+
+	filtered := make([]interface{}, 0, len(items))
+	for _, raw := range items {
+		createTime, _ := time.Parse(time.RFC3339, raw.(map[string]interface{})["createTime"].(string))
+		if after != "" && createTime.Before(afterTime) {
+			continue
+		}
+		if before != "" && !createTime.Before(beforeTime) {
+			continue
+		}
+		filtered = append(filtered, raw)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		iTime, _ := time.Parse(time.RFC3339, filtered[i].(map[string]interface{})["createTime"].(string))
+		jTime, _ := time.Parse(time.RFC3339, filtered[j].(map[string]interface{})["createTime"].(string))
+		return iTime.After(jTime)
+	})
+	return filtered
+}
+
+// firebaseRulesSourceDiffSuppress suppresses diffs in rule source that only
+// change whitespace or comments.
+func firebaseRulesSourceDiffSuppress(_, old, new string, _ *schema.ResourceData) bool {
+	if old == "" || new == "" {
+		return old == new
+	}
+	return firebaseRulesSourceEquivalent(old, new)
+}
+
+// firebaseRulesSourceFiles returns the ruleset source files configured through
+// rule, rule_file or files. rule_file must name an existing file.
+func firebaseRulesSourceFiles(rule, ruleFile, files interface{}, ruleType string) ([]interface{}, error) {
+	if path, _ := ruleFile.(string); path != "" {
+		contents, wasPath, err := pathOrContents(path)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading rule_file %q: %s", path, err)
+		}
+		if !wasPath {
+			return nil, fmt.Errorf("Error reading rule_file %q: file does not exist", path)
+		}
+		rule = contents
+	}
+	return expandFirebaseRulesFiles(rule, files, ruleType), nil
+}
+
+// firebaseRulesSourceSha256 hashes the names and significant tokens of the
+// source files, so that whitespace and comment changes keep the same hash.
+// Files that cannot be tokenized are hashed as is.
+func firebaseRulesSourceSha256(files []interface{}) string {
+	h := sha256.New()
+	for _, raw := range files {
+		file := raw.(map[string]interface{})
+		content, _ := file["content"].(string)
+		fmt.Fprintf(h, "%v\x00", file["name"])
+		if tokens, err := lexFirebaseRules(content); err == nil {
+			for _, tok := range firebaseRulesSignificantTokens(tokens) {
+				fmt.Fprintf(h, "%s\x00", tok.text)
+			}
+		} else {
+			h.Write([]byte(content))
+		}
+		h.Write([]byte{'\x01'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func firebaseRulesSourceHasChange(d *schema.ResourceDiff) bool {
+	for _, k := range []string{"rule", "files", "source_sha256"} {
+		if d.HasChange(k) {
+			return true
+		}
+	}
+	return false
+}
+
+func firebaseRulesSourceKnown(d *schema.ResourceDiff) bool {
+	for _, k := range []string{"rule", "rule_file", "files"} {
+		if !d.NewValueKnown(k) {
+			return false
+		}
+	}
+	return true
+}
+
+// firebaseRulesSourceSha256CustomizeDiff plans source_sha256 from the
+// configured source. It differs from the hash Read computed from the live
+// ruleset whenever the source was edited, including in rule_file or outside of
+// Terraform. When forceNew is set a changed hash replaces the resource.
+func firebaseRulesSourceSha256CustomizeDiff(ruleType string, forceNew bool) schema.CustomizeDiffFunc {
+	return func(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		if !firebaseRulesSourceKnown(d) {
+			return d.SetNewComputed("source_sha256")
+		}
+
+		files, err := firebaseRulesSourceFiles(d.Get("rule"), d.Get("rule_file"), d.Get("files"), ruleType)
+		if err != nil {
+			return err
+		}
+
+		sha := firebaseRulesSourceSha256(files)
+		if d.Get("source_sha256").(string) == sha {
+			return nil
+		}
+		if err := d.SetNew("source_sha256", sha); err != nil {
+			return err
+		}
+		if forceNew && d.Id() != "" {
+			return d.ForceNew("source_sha256")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,445 @@
+package sidkik
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func Test_firebaseRulesIssuesError(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		wantErr  string
+	}{
+		{
+			name:     "no issues",
+			response: `{}`,
+		},
+		{
+			name: "warnings only",
+			response: `{
+				"issues": [
+					{
+						"sourcePosition": {"fileName": "firestore.rules", "line": 4, "column": 7},
+						"description": "Unused function: isOwner.",
+						"severity": "WARNING"
+					}
+				]
+			}`,
+		},
+		{
+			name: "errors",
+			response: `{
+				"issues": [
+					{
+						"sourcePosition": {"fileName": "firestore.rules", "line": 5, "column": 31},
+						"description": "mismatched input ';' expecting {'{', '/', PATH_SEGMENT}",
+						"severity": "ERROR"
+					},
+					{
+						"sourcePosition": {"fileName": "firestore.rules", "line": 4, "column": 7},
+						"description": "Unused function: isOwner.",
+						"severity": "WARNING"
+					},
+					{
+						"sourcePosition": {"fileName": "firestore.rules", "line": 9, "column": 1},
+						"description": "Unexpected '}'.",
+						"severity": "ERROR"
+					}
+				]
+			}`,
+			wantErr: "Error compiling Firebase Rules:\n" +
+				"firestore.rules:5:31: mismatched input ';' expecting {'{', '/', PATH_SEGMENT}\n" +
+				"firestore.rules:9:1: Unexpected '}'.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.response), &result); err != nil {
+				t.Fatal(err)
+			}
+			err := firebaseRulesIssuesError(result["issues"])
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("firebaseRulesIssuesError() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("firebaseRulesIssuesError() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_expiredFirebaseRulesets(t *testing.T) {
+	releases := `[
+		{
+			"name": "projects/my-project/releases/cloud.firestore",
+			"rulesetName": "projects/my-project/rulesets/firestore-6",
+			"createTime": "2021-12-10T00:00:00Z"
+		},
+		{
+			"name": "projects/my-project/releases/cloud.firestore/orders",
+			"rulesetName": "projects/my-project/rulesets/firestore-4"
+		},
+		{
+			"name": "projects/my-project/releases/firebase.storage/my-project.appspot.com",
+			"rulesetName": "projects/my-project/rulesets/storage-1"
+		}
+	]`
+	rulesets := `[
+		{
+			"name": "projects/my-project/rulesets/standalone",
+			"createTime": "2021-12-09T00:00:00Z",
+			"metadata": {"services": ["cloud.firestore"]}
+		},
+		{
+			"name": "projects/my-project/rulesets/firestore-1",
+			"createTime": "2021-12-10T00:00:00Z",
+			"metadata": {"services": ["cloud.firestore"]}
+		},
+		{
+			"name": "projects/my-project/rulesets/firestore-2",
+			"createTime": "2021-12-11T00:00:00Z",
+			"metadata": {"services": ["cloud.firestore"]}
+		},
+		{
+			"name": "projects/my-project/rulesets/orders-1",
+			"createTime": "2021-12-11T12:00:00Z",
+			"metadata": {"services": ["cloud.firestore"]}
+		},
+		{
+			"name": "projects/my-project/rulesets/firestore-3",
+			"createTime": "2021-12-12T00:00:00Z",
+			"metadata": {"services": ["cloud.firestore"]}
+		},
+		{
+			"name": "projects/my-project/rulesets/firestore-4",
+			"createTime": "2021-12-13T00:00:00Z",
+			"metadata": {"services": ["cloud.firestore"]}
+		},
+		{
+			"name": "projects/my-project/rulesets/firestore-5",
+			"createTime": "2021-12-14T00:00:00Z",
+			"metadata": {"services": ["cloud.firestore"]}
+		},
+		{
+			"name": "projects/my-project/rulesets/firestore-6",
+			"createTime": "2021-12-15T00:00:00Z",
+			"metadata": {"services": ["cloud.firestore"]}
+		},
+		{
+			"name": "projects/my-project/rulesets/storage-1",
+			"createTime": "2021-12-09T00:00:00Z",
+			"metadata": {"services": ["firebase.storage"]}
+		},
+		{
+			"name": "projects/my-project/rulesets/storage-2",
+			"createTime": "2021-12-08T00:00:00Z",
+			"metadata": {"services": ["firebase.storage"]}
+		}
+	]`
+
+	var releaseList, rulesetList []interface{}
+	if err := json.Unmarshal([]byte(releases), &releaseList); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(rulesets), &rulesetList); err != nil {
+		t.Fatal(err)
+	}
+
+	// firestore-1 to firestore-6 were created for the (default) database,
+	// possibly before retention was enabled, while standalone belongs to a
+	// sidkik_firebase_ruleset and carries no marker
+	markers := map[string]string{
+		"projects/my-project/rulesets/firestore-1": "cloud.firestore",
+		"projects/my-project/rulesets/firestore-2": "cloud.firestore",
+		"projects/my-project/rulesets/firestore-3": "cloud.firestore",
+		"projects/my-project/rulesets/firestore-4": "cloud.firestore",
+		"projects/my-project/rulesets/firestore-5": "cloud.firestore",
+		"projects/my-project/rulesets/firestore-6": "cloud.firestore",
+		"projects/my-project/rulesets/orders-1":    "cloud.firestore/orders",
+		"projects/my-project/rulesets/storage-2":   "firebase.storage/my-project.appspot.com",
+	}
+	sourceOf := func(name string) (interface{}, error) {
+		content := "service cloud.firestore {}\n"
+		if marker, ok := markers[name]; ok {
+			content += "\n" + firebaseRulesReleaseMarker + marker + "\n"
+		}
+		return map[string]interface{}{"files": []interface{}{
+			map[string]interface{}{"name": "firestore.rules", "content": content},
+		}}, nil
+	}
+
+	tests := []struct {
+		name      string
+		release   string
+		ruleset   string
+		retain    int
+		protected []string
+		want      []string
+	}{
+		{
+			name:    "keeps newest and released",
+			release: "projects/my-project/releases/cloud.firestore",
+			ruleset: "projects/my-project/rulesets/firestore-6",
+			retain:  2,
+			want: []string{
+				"projects/my-project/rulesets/firestore-3",
+				"projects/my-project/rulesets/firestore-2",
+				"projects/my-project/rulesets/firestore-1",
+			},
+		},
+		{
+			name:    "retain everything",
+			release: "projects/my-project/releases/cloud.firestore",
+			ruleset: "projects/my-project/rulesets/firestore-6",
+			retain:  6,
+			want:    []string{},
+		},
+		{
+			name:      "protected",
+			release:   "projects/my-project/releases/cloud.firestore",
+			ruleset:   "projects/my-project/rulesets/firestore-6",
+			retain:    2,
+			protected: []string{"projects/my-project/rulesets/firestore-2"},
+			want: []string{
+				"projects/my-project/rulesets/firestore-3",
+				"projects/my-project/rulesets/firestore-1",
+			},
+		},
+		{
+			name:    "other database",
+			release: "projects/my-project/releases/cloud.firestore/orders",
+			ruleset: "projects/my-project/rulesets/firestore-4",
+			retain:  1,
+			want: []string{
+				"projects/my-project/rulesets/orders-1",
+			},
+		},
+		{
+			name:    "other service",
+			release: "projects/my-project/releases/firebase.storage/my-project.appspot.com",
+			ruleset: "projects/my-project/rulesets/storage-1",
+			retain:  1,
+			want: []string{
+				"projects/my-project/rulesets/storage-2",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expiredFirebaseRulesets(releaseList, rulesetList, tt.protected, tt.release, tt.ruleset, tt.retain, sourceOf)
+			if err != nil {
+				t.Fatalf("expiredFirebaseRulesets() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expiredFirebaseRulesets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_filterFirebaseRulesByCreateTime(t *testing.T) {
+	var items []interface{}
+	if err := json.Unmarshal([]byte(`[
+		{"name": "projects/my-project/rulesets/a", "createTime": "2021-12-10T00:00:00Z"},
+		{"name": "projects/my-project/rulesets/c", "createTime": "2021-12-12T00:00:00Z"},
+		{"name": "projects/my-project/rulesets/b", "createTime": "2021-12-11T00:00:00.123456Z"}
+	]`), &items); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		after  string
+		before string
+		want   []string
+	}{
+		{
+			name: "no bounds sorts newest first",
+			want: []string{"c", "b", "a"},
+		},
+		{
+			name:  "after is inclusive",
+			after: "2021-12-11T00:00:00.123456Z",
+			want:  []string{"c", "b"},
+		},
+		{
+			name:   "before is exclusive",
+			before: "2021-12-12T00:00:00Z",
+			want:   []string{"b", "a"},
+		},
+		{
+			name:   "both bounds",
+			after:  "2021-12-10T12:00:00Z",
+			before: "2021-12-12T00:00:00Z",
+			want:   []string{"b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, item := range filterFirebaseRulesByCreateTime(items, tt.after, tt.before) {
+				got = append(got, strings.TrimPrefix(item.(map[string]interface{})["name"].(string), "projects/my-project/rulesets/"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterFirebaseRulesByCreateTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_firebaseRulesSourceFiles(t *testing.T) {
+	files, err := firebaseRulesSourceFiles("", "./test-fixtures/firestore.rules", nil, "firestore.rules")
+	if err != nil {
+		t.Fatalf("firebaseRulesSourceFiles() error = %v", err)
+	}
+	if len(files) != 1 || files[0].(map[string]interface{})["name"] != "firestore.rules" {
+		t.Fatalf("firebaseRulesSourceFiles() = %v, want a single firestore.rules file", files)
+	}
+	if content := files[0].(map[string]interface{})["content"].(string); !strings.Contains(content, "service cloud.firestore") {
+		t.Errorf("firebaseRulesSourceFiles() content = %q, want the fixture contents", content)
+	}
+
+	if _, err := firebaseRulesSourceFiles("", "./test-fixtures/missing.rules", nil, "firestore.rules"); err == nil {
+		t.Errorf("firebaseRulesSourceFiles() expected an error for a missing rule_file")
+	}
+}
+
+func Test_firebaseRulesSourceSha256(t *testing.T) {
+	file := func(name, content string) []interface{} {
+		return []interface{}{map[string]interface{}{"name": name, "content": content}}
+	}
+
+	base := firebaseRulesSourceSha256(file("firestore.rules", "service cloud.firestore {\n  match /{document=**} {\n    allow read: if true;\n  }\n}\n"))
+
+	if got := firebaseRulesSourceSha256(file("firestore.rules", "// comment\nservice cloud.firestore { match /{document=**} { allow read: if true; } }")); got != base {
+		t.Errorf("firebaseRulesSourceSha256() = %s for reformatted source, want %s", got, base)
+	}
+	if got := firebaseRulesSourceSha256(file("firestore.rules", "service cloud.firestore { match /{document=**} { allow read: if false; } }")); got == base {
+		t.Errorf("firebaseRulesSourceSha256() unchanged after a semantic edit")
+	}
+	if got := firebaseRulesSourceSha256(file("other.rules", "service cloud.firestore { match /{document=**} { allow read: if true; } }")); got == base {
+		t.Errorf("firebaseRulesSourceSha256() unchanged after renaming the file")
+	}
+}
+
+func Test_firebaseRulesSourceExactlyOneOf(t *testing.T) {
+	resources := map[string]*schema.Resource{
+		"sidkik_firebase_firestore_rule": resourceFirebaseFirestoreRule(),
+		"sidkik_firebase_storage_rule":   resourceFirebaseStorageRule(),
+	}
+	cases := map[string]struct {
+		raw     map[string]interface{}
+		wantErr bool
+	}{
+		"rule": {
+			raw: map[string]interface{}{"rule": "service cloud.firestore {}"},
+		},
+		"rule_file": {
+			raw: map[string]interface{}{"rule_file": "firestore.rules"},
+		},
+		"files": {
+			raw: map[string]interface{}{"files": []interface{}{
+				map[string]interface{}{"name": "firestore.rules", "content": "service cloud.firestore {}"},
+			}},
+		},
+		"none": {
+			raw:     map[string]interface{}{},
+			wantErr: true,
+		},
+		"rule and rule_file": {
+			raw:     map[string]interface{}{"rule": "service cloud.firestore {}", "rule_file": "firestore.rules"},
+			wantErr: true,
+		},
+	}
+	for rn, r := range resources {
+		for tn, tc := range cases {
+			diags := r.Validate(terraform.NewResourceConfigRaw(tc.raw))
+			if diags.HasError() != tc.wantErr {
+				t.Errorf("bad: %s %s, expected error %t, got %v", rn, tn, tc.wantErr, diags)
+			}
+		}
+	}
+}
+
+func Test_firebaseRulesRestorePreviousAction(t *testing.T) {
+	tests := []struct {
+		name           string
+		previous       string
+		previousExists bool
+		createdRelease bool
+		want           string
+	}{
+		{
+			name:           "previous ruleset",
+			previous:       "projects/my-project/rulesets/abc",
+			previousExists: true,
+			want:           "release",
+		},
+		{
+			name:     "previous ruleset deleted",
+			previous: "projects/my-project/rulesets/abc",
+			want:     "keep",
+		},
+		{
+			name:           "created release",
+			createdRelease: true,
+			want:           "delete",
+		},
+		{
+			name: "imported",
+			want: "keep",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := firebaseRulesRestorePreviousAction(tt.previous, tt.previousExists, tt.createdRelease); got != tt.want {
+				t.Errorf("firebaseRulesRestorePreviousAction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_markFirebaseRulesetRelease(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceFirebaseFirestoreRule().Schema, map[string]interface{}{
+		"project":  "my-project",
+		"database": "orders",
+	})
+	content := "service cloud.firestore {\n  match /databases/{database}/documents {}\n}\n"
+	obj := map[string]interface{}{
+		"source": map[string]interface{}{
+			"files": []interface{}{
+				map[string]interface{}{"name": "functions.rules", "content": "function f() { return true; }"},
+				map[string]interface{}{"name": "firestore.rules", "content": content},
+			},
+		},
+	}
+
+	if err := markFirebaseRulesetRelease(d, &Config{}, obj, firebaseFirestoreReleaseTemplate(d)); err != nil {
+		t.Fatalf("markFirebaseRulesetRelease() error = %v", err)
+	}
+	if got := firebaseRulesetRelease(obj["source"]); got != "cloud.firestore/orders" {
+		t.Errorf("firebaseRulesetRelease() = %q, want cloud.firestore/orders", got)
+	}
+
+	files := flattenFirebaseRulesFiles(obj["source"], nil, nil)
+	if got := files[1].(map[string]interface{})["content"]; got != content {
+		t.Errorf("flattenFirebaseRulesFiles() content = %q, want the marker stripped to %q", got, content)
+	}
+	if got := files[0].(map[string]interface{})["content"]; got != "function f() { return true; }" {
+		t.Errorf("flattenFirebaseRulesFiles() changed the first file to %q", got)
+	}
+
+	if got := firebaseRulesetRelease(map[string]interface{}{"files": files}); got != "" {
+		t.Errorf("firebaseRulesetRelease() = %q for unmarked source, want none", got)
+	}
+}
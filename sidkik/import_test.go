@@ -0,0 +1,98 @@
+package sidkik
+
+import (
+	"testing"
+)
+
+func TestParseImportId(t *testing.T) {
+	firestoreFormats := []string{
+		"^projects/(?P<project>[^/]+)/releases/cloud\\.firestore/(?P<database>[^/]+)$",
+		"^projects/(?P<project>[^/]+)/releases/cloud\\.firestore$",
+		"^(?P<project>[^/]+)/(?P<database>[^/]+)$",
+		"^(?P<project>[^/]+)$",
+	}
+	storageFormats := []string{
+		"^projects/(?P<project>[^/]+)/releases/firebase\\.storage/(?P<bucket>[^/]+)$",
+		"^(?P<project>[^/]+)/(?P<bucket>[^/]+)$",
+		"^(?P<project>[^/]+)$",
+	}
+
+	cases := map[string]struct {
+		ImportId             string
+		IdRegexes            []string
+		Config               *Config
+		ExpectedSchemaValues map[string]interface{}
+		ExpectError          bool
+	}{
+		"firestore release name": {
+			ImportId:             "projects/my-project/releases/cloud.firestore",
+			IdRegexes:            firestoreFormats,
+			Config:               &Config{},
+			ExpectedSchemaValues: map[string]interface{}{"project": "my-project"},
+		},
+		"firestore named database": {
+			ImportId:  "projects/my-project/releases/cloud.firestore/orders",
+			IdRegexes: firestoreFormats,
+			Config:    &Config{},
+			ExpectedSchemaValues: map[string]interface{}{
+				"project":  "my-project",
+				"database": "orders",
+			},
+		},
+		"firestore project only": {
+			ImportId:             "my-project",
+			IdRegexes:            firestoreFormats,
+			Config:               &Config{},
+			ExpectedSchemaValues: map[string]interface{}{"project": "my-project"},
+		},
+		"storage release name": {
+			ImportId:  "projects/my-project/releases/firebase.storage/my-project.appspot.com",
+			IdRegexes: storageFormats,
+			Config:    &Config{},
+			ExpectedSchemaValues: map[string]interface{}{
+				"project": "my-project",
+				"bucket":  "my-project.appspot.com",
+			},
+		},
+		"storage project and bucket": {
+			ImportId:  "my-project/my-bucket",
+			IdRegexes: storageFormats,
+			Config:    &Config{},
+			ExpectedSchemaValues: map[string]interface{}{
+				"project": "my-project",
+				"bucket":  "my-bucket",
+			},
+		},
+		"invalid format": {
+			ImportId:    "projects/my-project/releases/other",
+			IdRegexes:   firestoreFormats,
+			Config:      &Config{},
+			ExpectError: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		d := &ResourceDataMock{
+			FieldsInSchema: make(map[string]interface{}),
+			id:             tc.ImportId,
+		}
+		err := parseImportId(tc.IdRegexes, d, tc.Config)
+
+		if tc.ExpectError {
+			if err == nil {
+				t.Errorf("bad: %s, expected an error", tn)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("bad: %s, unexpected error: %s", tn, err)
+			continue
+		}
+
+		for k, expectedValue := range tc.ExpectedSchemaValues {
+			if v := d.Get(k); v != expectedValue {
+				t.Errorf("bad: %s, %q was %#v, expected %#v", tn, k, v, expectedValue)
+			}
+		}
+	}
+}
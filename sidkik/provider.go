@@ -8,6 +8,7 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
 	googleoauth "golang.org/x/oauth2/google"
 )
@@ -115,6 +116,23 @@ func Provider() *schema.Provider {
 				}, nil),
 			},
 
+			"retain_rulesets": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  `Number of most recent rulesets each firestore and storage rule resource keeps of the rulesets created for its release. Older ones are deleted unless a release references them. Rulesets without a release marker, e.g. those of sidkik_firebase_ruleset, are never deleted`,
+			},
+
+			"rules_lint_level": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"off", "warning", "error"}, false),
+				DefaultFunc: schema.MultiEnvDefaultFunc([]string{
+					"SIDKIK_RULES_LINT_LEVEL",
+				}, "warning"),
+				Description: `How dangerous patterns found in firestore and storage rule source are reported. warning reports them as warnings once the rule is applied, as plan cannot show warnings. error fails the plan and off disables the checks`,
+			},
+
 			"firebase_rules_custom_endpoint": {
 				Type:         schema.TypeString,
 				Optional:     true,
@@ -123,6 +141,23 @@ func Provider() *schema.Provider {
 					"SIDKIK_FIREBASE_RULES_CUSTOM_ENDPOINT",
 				}, DefaultBasePaths[FirebaseRulesBasePathKey]),
 			},
+			"firebase_custom_endpoint": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateCustomEndpoint,
+				DefaultFunc: schema.MultiEnvDefaultFunc([]string{
+					"SIDKIK_FIREBASE_CUSTOM_ENDPOINT",
+				}, DefaultBasePaths[FirebaseBasePathKey]),
+			},
+			"firebase_database_custom_endpoint": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateCustomEndpoint,
+				Description:  `Base path of the Realtime Database REST API. {{instance}} is replaced with the instance of each sidkik_firebase_database_rules resource. When set, it is also used for instances given as the host of a regional database`,
+				DefaultFunc: schema.MultiEnvDefaultFunc([]string{
+					"SIDKIK_FIREBASE_DATABASE_CUSTOM_ENDPOINT",
+				}, DefaultBasePaths[FirebaseDatabaseBasePathKey]),
+			},
 			"identity_platform_custom_endpoint": {
 				Type:         schema.TypeString,
 				Optional:     true,
@@ -149,6 +184,10 @@ func Provider() *schema.Provider {
 		DataSourcesMap: map[string]*schema.Resource{
 			"sidkik_firebase_firestore_rule": dataSourceFirebaseFirestoreRule(),
 			"sidkik_firebase_storage_rule":   dataSourceFirebaseStorageRule(),
+			"sidkik_firebase_rules_releases": dataSourceFirebaseRulesReleases(),
+			"sidkik_firebase_rules_coverage": dataSourceFirebaseRulesCoverage(),
+			"sidkik_firebase_rules_format":   dataSourceFirebaseRulesFormat(),
+			"sidkik_firebase_rulesets":       dataSourceFirebaseRulesets(),
 			"sidkik_firebase_auth_config":    dataSourceFirebaseAuthConfig(),
 		},
 		ResourcesMap: resourceMap(),
@@ -162,9 +201,14 @@ func Provider() *schema.Provider {
 
 func resourceMap() map[string]*schema.Resource {
 	return map[string]*schema.Resource{
-		"sidkik_firebase_firestore_rule": resourceFirebaseFirestoreRule(),
-		"sidkik_firebase_storage_rule":   resourceFirebaseStorageRule(),
-		"sidkik_firebase_auth_config":    resourceFirebaseAuthConfig(),
+		"sidkik_firebase_firestore_rule":  resourceFirebaseFirestoreRule(),
+		"sidkik_firebase_storage_rule":    resourceFirebaseStorageRule(),
+		"sidkik_firebase_ruleset":         resourceFirebaseRuleset(),
+		"sidkik_firebase_release":         resourceFirebaseRelease(),
+		"sidkik_firebase_rules_promotion": resourceFirebaseRulesPromotion(),
+		"sidkik_firebase_rules_rollback":  resourceFirebaseRulesRollback(),
+		"sidkik_firebase_database_rules":  resourceFirebaseDatabaseRules(),
+		"sidkik_firebase_auth_config":     resourceFirebaseAuthConfig(),
 	}
 }
 
@@ -196,6 +240,14 @@ func providerConfigure(ctx context.Context, d *schema.ResourceData, p *schema.Pr
 		config.RequestReason = v.(string)
 	}
 
+	if v, ok := d.GetOk("retain_rulesets"); ok {
+		config.RetainRulesets = v.(int)
+	}
+
+	if v, ok := d.GetOk("rules_lint_level"); ok {
+		config.RulesLintLevel = v.(string)
+	}
+
 	// Check for primary credentials in config. Note that if neither is set, ADCs
 	// will be used if available.
 	if v, ok := d.GetOk("access_token"); ok {
@@ -244,6 +296,8 @@ func providerConfigure(ctx context.Context, d *schema.ResourceData, p *schema.Pr
 
 	// products
 	config.FirebaseRulesBasePath = d.Get("firebase_rules_custom_endpoint").(string)
+	config.FirebaseBasePath = d.Get("firebase_custom_endpoint").(string)
+	config.FirebaseDatabaseBasePath = d.Get("firebase_database_custom_endpoint").(string)
 	config.IdentityPlatformBasePath = d.Get("identity_platform_custom_endpoint").(string)
 	config.MobileSDKBasePath = d.Get("mobile_sdk_custom_endpoint").(string)
 
@@ -156,7 +156,12 @@ func getCachedConfig(ctx context.Context, d *schema.ResourceData, configureFunc
 	}
 	path := filepath.Join(envPath, vcrFileName(testName))
 
-	rec, err := recorder.NewAsMode(path, vcrMode, config.client.Transport)
+	// Both clients share the recorder so each test keeps a single cassette.
+	realTransport := &firebaseDatabaseRoutingTransport{
+		defaultTransport:  config.client.Transport,
+		databaseTransport: config.firebaseDatabaseClient.Transport,
+	}
+	rec, err := recorder.NewAsMode(path, vcrMode, realTransport)
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
@@ -203,10 +208,26 @@ func getCachedConfig(ctx context.Context, d *schema.ResourceData, configureFunc
 		return false
 	})
 	config.client.Transport = rec
+	config.firebaseDatabaseClient.Transport = rec
 	configs[testName] = config
 	return config, nil
 }
 
+// firebaseDatabaseRoutingTransport sends requests to Realtime Database hosts
+// with the transport of the Realtime Database client and all others with the
+// default one.
+type firebaseDatabaseRoutingTransport struct {
+	defaultTransport  http.RoundTripper
+	databaseTransport http.RoundTripper
+}
+
+func (t *firebaseDatabaseRoutingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if host := req.URL.Hostname(); strings.HasSuffix(host, ".firebaseio.com") || strings.HasSuffix(host, ".firebasedatabase.app") {
+		return t.databaseTransport.RoundTrip(req)
+	}
+	return t.defaultTransport.RoundTrip(req)
+}
+
 // We need to explicitly close the VCR recorder to save the cassette
 func closeRecorder(t *testing.T) {
 	if config, ok := configs[t.Name()]; ok {
@@ -1,11 +1,15 @@
 package sidkik
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceFirebaseAuthConfig() *schema.Resource {
@@ -15,9 +19,9 @@ func resourceFirebaseAuthConfig() *schema.Resource {
 		Update: resourceFirebaseAuthConfigUpdate,
 		Delete: resourceFirebaseAuthConfigDelete,
 
-		// Importer: &schema.ResourceImporter{
-		// 	State: resourceComputeHealthCheckImport,
-		// },
+		Importer: &schema.ResourceImporter{
+			State: resourceFirebaseAuthConfigImport,
+		},
 
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(4 * time.Minute),
@@ -25,16 +29,261 @@ func resourceFirebaseAuthConfig() *schema.Resource {
 			Delete: schema.DefaultTimeout(4 * time.Minute),
 		},
 
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Type:    resourceFirebaseAuthConfigResourceV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceFirebaseAuthConfigUpgradeV0,
+				Version: 0,
+			},
+		},
+
 		// CustomizeDiff: rulesCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"email": {
-				Type: schema.TypeBool,
-				// Default:     false,
+				Type:        schema.TypeList,
 				Optional:    true,
 				Computed:    true,
-				ForceNew:    false,
-				Description: `enable email signin`,
+				MaxItems:    1,
+				Description: `email sign-in configuration`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"enabled": {
+							Type:        schema.TypeBool,
+							Required:    true,
+							Description: `enable email signin`,
+						},
+						"password_required": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+							Description: `require a password for email signin. Set to false to allow passwordless email link signin`,
+						},
+					},
+				},
+			},
+			"phone_number": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Computed:    true,
+				MaxItems:    1,
+				Description: `phone number sign-in configuration`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"enabled": {
+							Type:        schema.TypeBool,
+							Required:    true,
+							Description: `enable phone number signin`,
+						},
+						"test_phone_numbers": {
+							Type:        schema.TypeMap,
+							Optional:    true,
+							Description: `map of fictional phone numbers, e.g. +1 555-555-1234, to the verification code accepted for them`,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+					},
+				},
+			},
+			"anonymous": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Computed:    true,
+				MaxItems:    1,
+				Description: `anonymous sign-in configuration`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"enabled": {
+							Type:        schema.TypeBool,
+							Required:    true,
+							Description: `enable anonymous signin`,
+						},
+					},
+				},
+			},
+			"allow_duplicate_emails": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+				Description: `allow more than one account to use the same email address`,
+			},
+			"mfa": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Computed:    true,
+				MaxItems:    1,
+				Description: `multi-factor authentication configuration`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"state": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice([]string{"DISABLED", "ENABLED", "MANDATORY"}, false),
+							Description:  `whether multi-factor authentication is DISABLED, ENABLED for users to opt in, or MANDATORY`,
+						},
+						"enabled_providers": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Description: `second factors users can enroll, e.g. PHONE_SMS`,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validation.StringInSlice([]string{"PHONE_SMS"}, false),
+							},
+						},
+						"provider_config": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Description: `configuration of second factors other than SMS`,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"state": {
+										Type:         schema.TypeString,
+										Required:     true,
+										ValidateFunc: validation.StringInSlice([]string{"DISABLED", "ENABLED", "MANDATORY"}, false),
+										Description:  `whether the second factor is DISABLED, ENABLED or MANDATORY`,
+									},
+									"totp_provider_config": {
+										Type:        schema.TypeList,
+										Optional:    true,
+										MaxItems:    1,
+										Description: `time-based one-time password (TOTP) configuration`,
+										Elem: &schema.Resource{
+											Schema: map[string]*schema.Schema{
+												"adjacent_intervals": {
+													Type:         schema.TypeInt,
+													Optional:     true,
+													Computed:     true,
+													ValidateFunc: validation.IntBetween(1, 10),
+													Description:  `number of 30 second intervals before and after the current one in which a TOTP code is still accepted, from 1 to 10. Uses the API default when unset`,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"password_policy": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Computed:    true,
+				MaxItems:    1,
+				Description: `requirements passwords must meet`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"enforcement_state": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice([]string{"ENFORCE", "OFF"}, false),
+							Description:  `whether the password policy is enforced. One of ENFORCE or OFF`,
+						},
+						"force_upgrade_on_signin": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Description: `require users whose password does not meet the policy to change it when they sign in`,
+						},
+						"min_password_length": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Computed:     true,
+							ValidateFunc: validation.IntBetween(6, 30),
+							Description:  `minimum password length, from 6 to 30. Defaults to 6`,
+						},
+						"max_password_length": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Computed:     true,
+							ValidateFunc: validation.IntBetween(6, 4096),
+							Description:  `maximum password length, up to 4096. Defaults to 4096`,
+						},
+						"contains_lowercase_character": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Description: `require a lowercase character`,
+						},
+						"contains_uppercase_character": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Description: `require an uppercase character`,
+						},
+						"contains_numeric_character": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Description: `require a numeric character`,
+						},
+						"contains_non_alphanumeric_character": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Description: `require a non-alphanumeric character`,
+						},
+					},
+				},
+			},
+			"enable_improved_email_privacy": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+				Description: `enable email enumeration protection, so that sign-in and password reset do not reveal whether an email address is registered`,
+			},
+			"blocking_functions": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Computed:    true,
+				MaxItems:    1,
+				Description: `blocking functions run before users are created or sign in`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"triggers": {
+							Type:        schema.TypeSet,
+							Optional:    true,
+							Description: `functions triggered by authentication events`,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"event_type": {
+										Type:         schema.TypeString,
+										Required:     true,
+										ValidateFunc: validation.StringInSlice([]string{"beforeCreate", "beforeSignIn", "beforeSendEmail", "beforeSendSms"}, false),
+										Description:  `event that triggers the function, e.g. beforeCreate or beforeSignIn`,
+									},
+									"function_uri": {
+										Type:        schema.TypeString,
+										Required:    true,
+										Description: `HTTP URI of the function to trigger`,
+									},
+								},
+							},
+						},
+						"forward_inbound_credentials": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							MaxItems:    1,
+							Description: `credentials of the identity provider passed to the functions`,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"id_token": {
+										Type:        schema.TypeBool,
+										Optional:    true,
+										Description: `pass the identity provider's ID token`,
+									},
+									"access_token": {
+										Type:        schema.TypeBool,
+										Optional:    true,
+										Description: `pass the identity provider's OAuth access token`,
+									},
+									"refresh_token": {
+										Type:        schema.TypeBool,
+										Optional:    true,
+										Description: `pass the identity provider's OAuth refresh token`,
+									},
+								},
+							},
+						},
+					},
+				},
 			},
 			"name": {
 				Type:        schema.TypeString,
@@ -53,6 +302,7 @@ func resourceFirebaseAuthConfig() *schema.Resource {
 			"authorized_domains": {
 				Type:        schema.TypeList,
 				Optional:    true,
+				Computed:    true,
 				Description: `list of authorized domains for authentication`,
 				Elem: &schema.Schema{
 					Type: schema.TypeString,
@@ -106,6 +356,34 @@ func resourceFirebaseAuthConfigRead(d *schema.ResourceData, meta interface{}) er
 		return fmt.Errorf("Error reading AuthConfig: %s", err)
 	}
 
+	if err := d.Set("phone_number", flattenPhoneNumber(res["signIn"], d, config)); err != nil {
+		return fmt.Errorf("Error reading AuthConfig: %s", err)
+	}
+
+	if err := d.Set("anonymous", flattenAnonymous(res["signIn"], d, config)); err != nil {
+		return fmt.Errorf("Error reading AuthConfig: %s", err)
+	}
+
+	if err := d.Set("allow_duplicate_emails", flattenAllowDuplicateEmails(res["signIn"], d, config)); err != nil {
+		return fmt.Errorf("Error reading AuthConfig: %s", err)
+	}
+
+	if err := d.Set("mfa", flattenMfa(res["mfa"], d, config)); err != nil {
+		return fmt.Errorf("Error reading AuthConfig: %s", err)
+	}
+
+	if err := d.Set("password_policy", flattenPasswordPolicy(res["passwordPolicyConfig"], d, config)); err != nil {
+		return fmt.Errorf("Error reading AuthConfig: %s", err)
+	}
+
+	if err := d.Set("enable_improved_email_privacy", flattenEnableImprovedEmailPrivacy(res["emailPrivacyConfig"], d, config)); err != nil {
+		return fmt.Errorf("Error reading AuthConfig: %s", err)
+	}
+
+	if err := d.Set("blocking_functions", flattenBlockingFunctions(res["blockingFunctions"], d, config)); err != nil {
+		return fmt.Errorf("Error reading AuthConfig: %s", err)
+	}
+
 	if err := d.Set("authorized_domains", flattenAuthorizedDomains(res["authorizedDomains"], d, config)); err != nil {
 		return fmt.Errorf("Error reading AuthConfig: %s", err)
 	}
@@ -130,7 +408,18 @@ func resourceFirebaseAuthConfigUpdate(d *schema.ResourceData, meta interface{})
 		return err
 	}
 
-	url, err := replaceVars(d, config, "{{IdentityPlatformBasePath}}projects/{{project}}/config?updateMask=signIn.email,authorizedDomains")
+	updateMask := resourceFirebaseAuthConfigUpdateMask(d)
+	if len(updateMask) == 0 {
+		// an empty mask would overwrite the whole config
+		log.Printf("[DEBUG] No changes to auth config")
+		return resourceFirebaseAuthConfigRead(d, meta)
+	}
+
+	url, err := replaceVars(d, config, "{{IdentityPlatformBasePath}}projects/{{project}}/config")
+	if err != nil {
+		return err
+	}
+	url, err = addQueryParams(url, map[string]string{"updateMask": strings.Join(updateMask, ",")})
 	if err != nil {
 		return err
 	}
@@ -141,8 +430,18 @@ func resourceFirebaseAuthConfigUpdate(d *schema.ResourceData, meta interface{})
 	}
 	configObj := make(map[string]interface{})
 	configObj["email"] = d.Get("email")
+	configObj["phone_number"] = d.Get("phone_number")
+	configObj["anonymous"] = d.Get("anonymous")
+	configObj["allow_duplicate_emails"] = d.Get("allow_duplicate_emails")
+	configObj["mfa"] = d.Get("mfa")
+	configObj["password_policy"] = d.Get("password_policy")
+	configObj["enable_improved_email_privacy"] = d.Get("enable_improved_email_privacy")
+	configObj["blocking_functions"] = d.Get("blocking_functions")
 	configObj["authorized_domains"] = d.Get("authorized_domains")
 	configObj, err = resourceFirebaseAuthConfigPatchEncoder(d, meta, configObj)
+	if err != nil {
+		return err
+	}
 
 	// grab the existing config - there are rules by default when the firebase account is created
 	_, err = sendRequest(config, "PATCH", project, url, userAgent, configObj)
@@ -151,10 +450,46 @@ func resourceFirebaseAuthConfigUpdate(d *schema.ResourceData, meta interface{})
 		return handleNotFoundError(err, d, fmt.Sprintf("AuthConfig %q", d.Id()))
 	}
 
-	log.Printf("[INFO] Updated auth config")
+	log.Printf("[INFO] Updated auth config fields %s", strings.Join(updateMask, ","))
 	return resourceFirebaseAuthConfigRead(d, meta)
 }
 
+// firebaseAuthConfigFieldMask is a field of the resource and the path of the
+// config it is sent as.
+type firebaseAuthConfigFieldMask struct {
+	field string
+	mask  string
+}
+
+var firebaseAuthConfigFieldMasks = []firebaseAuthConfigFieldMask{
+	{field: "email", mask: "signIn.email"},
+	{field: "phone_number", mask: "signIn.phoneNumber"},
+	{field: "anonymous", mask: "signIn.anonymous"},
+	{field: "allow_duplicate_emails", mask: "signIn.allowDuplicateEmails"},
+	{field: "mfa", mask: "mfa"},
+	{field: "password_policy", mask: "passwordPolicyConfig"},
+	{field: "enable_improved_email_privacy", mask: "emailPrivacyConfig.enableImprovedEmailPrivacy"},
+	{field: "blocking_functions.0.triggers", mask: "blockingFunctions.triggers"},
+	{field: "blocking_functions.0.forward_inbound_credentials", mask: "blockingFunctions.forwardInboundCredentials"},
+	{field: "authorized_domains", mask: "authorizedDomains"},
+}
+
+// resourceFirebaseAuthConfigUpdateMask returns the paths of the config to
+// update, one for each changed field. Fields that are not set in config are
+// left untouched, so that other parts of the config can be managed elsewhere.
+func resourceFirebaseAuthConfigUpdateMask(d TerraformResourceData) []string {
+	updateMask := []string{}
+	for _, fm := range firebaseAuthConfigFieldMasks {
+		// on create, fields set to their zero value are not a change but are
+		// still sent
+		_, set := d.GetOkExists(fm.field)
+		if d.HasChange(fm.field) || (d.Id() == "" && set) {
+			updateMask = append(updateMask, fm.mask)
+		}
+	}
+	return updateMask
+}
+
 func resourceFirebaseAuthConfigCreate(d *schema.ResourceData, meta interface{}) error {
 	// will always be an update
 	return resourceFirebaseAuthConfigUpdate(d, meta)
@@ -165,13 +500,187 @@ func flattenAuthConfigName(v interface{}, d *schema.ResourceData, config *Config
 }
 
 func flattenEmail(v interface{}, d *schema.ResourceData, config *Config) interface{} {
-	signIn := v.(map[string]interface{})
-	if signIn["email"] == nil {
-		return false
+	signIn, _ := v.(map[string]interface{})
+	email, ok := signIn["email"].(map[string]interface{})
+	if !ok {
+		return []interface{}{}
 	}
-	email := signIn["email"].(map[string]interface{})
 
-	return email["enabled"]
+	enabled, _ := email["enabled"].(bool)
+	passwordRequired, _ := email["passwordRequired"].(bool)
+	return []interface{}{
+		map[string]interface{}{
+			"enabled":           enabled,
+			"password_required": passwordRequired,
+		},
+	}
+}
+
+func flattenPhoneNumber(v interface{}, d *schema.ResourceData, config *Config) interface{} {
+	signIn, _ := v.(map[string]interface{})
+	phoneNumber, ok := signIn["phoneNumber"].(map[string]interface{})
+	if !ok {
+		return []interface{}{}
+	}
+
+	enabled, _ := phoneNumber["enabled"].(bool)
+	return []interface{}{
+		map[string]interface{}{
+			"enabled":            enabled,
+			"test_phone_numbers": phoneNumber["testPhoneNumbers"],
+		},
+	}
+}
+
+func flattenAnonymous(v interface{}, d *schema.ResourceData, config *Config) interface{} {
+	signIn, _ := v.(map[string]interface{})
+	anonymous, ok := signIn["anonymous"].(map[string]interface{})
+	if !ok {
+		return []interface{}{}
+	}
+
+	enabled, _ := anonymous["enabled"].(bool)
+	return []interface{}{
+		map[string]interface{}{
+			"enabled": enabled,
+		},
+	}
+}
+
+func flattenAllowDuplicateEmails(v interface{}, d *schema.ResourceData, config *Config) interface{} {
+	signIn, _ := v.(map[string]interface{})
+	allowDuplicateEmails, _ := signIn["allowDuplicateEmails"].(bool)
+	return allowDuplicateEmails
+}
+
+func flattenMfa(v interface{}, d *schema.ResourceData, config *Config) interface{} {
+	mfa, ok := v.(map[string]interface{})
+	if !ok {
+		return []interface{}{}
+	}
+
+	providerConfigs := make([]interface{}, 0)
+	if l, ok := mfa["providerConfigs"].([]interface{}); ok {
+		for _, raw := range l {
+			providerConfig := raw.(map[string]interface{})
+			transformed := map[string]interface{}{
+				"state": providerConfig["state"],
+			}
+			if totp, ok := providerConfig["totpProviderConfig"].(map[string]interface{}); ok {
+				transformed["totp_provider_config"] = []interface{}{
+					map[string]interface{}{
+						"adjacent_intervals": flattenAuthConfigInteger(totp["adjacentIntervals"]),
+					},
+				}
+			}
+			providerConfigs = append(providerConfigs, transformed)
+		}
+	}
+
+	return []interface{}{
+		map[string]interface{}{
+			"state":             mfa["state"],
+			"enabled_providers": mfa["enabledProviders"],
+			"provider_config":   providerConfigs,
+		},
+	}
+}
+
+func flattenAuthConfigInteger(v interface{}) interface{} {
+	// Handles the string fixed64 format
+	if strVal, ok := v.(string); ok {
+		if intVal, err := strconv.ParseInt(strVal, 10, 64); err == nil {
+			return intVal
+		}
+	}
+
+	// number values are represented as float64
+	if floatVal, ok := v.(float64); ok {
+		return int(floatVal)
+	}
+
+	return v
+}
+
+func flattenPasswordPolicy(v interface{}, d *schema.ResourceData, config *Config) interface{} {
+	passwordPolicy, ok := v.(map[string]interface{})
+	if !ok {
+		return []interface{}{}
+	}
+
+	forceUpgradeOnSignin, _ := passwordPolicy["forceUpgradeOnSignin"].(bool)
+	transformed := map[string]interface{}{
+		"enforcement_state":       passwordPolicy["passwordPolicyEnforcementState"],
+		"force_upgrade_on_signin": forceUpgradeOnSignin,
+	}
+
+	// the API keeps one version of the policy, the current one
+	var options map[string]interface{}
+	if versions, ok := passwordPolicy["passwordPolicyVersions"].([]interface{}); ok && len(versions) > 0 {
+		version, _ := versions[0].(map[string]interface{})
+		options, _ = version["customStrengthOptions"].(map[string]interface{})
+	}
+	transformed["min_password_length"] = flattenAuthConfigInteger(options["minPasswordLength"])
+	transformed["max_password_length"] = flattenAuthConfigInteger(options["maxPasswordLength"])
+	for field, option := range firebaseAuthConfigPasswordCharacterOptions {
+		contains, _ := options[option].(bool)
+		transformed[field] = contains
+	}
+
+	return []interface{}{transformed}
+}
+
+func flattenEnableImprovedEmailPrivacy(v interface{}, d *schema.ResourceData, config *Config) interface{} {
+	emailPrivacy, _ := v.(map[string]interface{})
+	enableImprovedEmailPrivacy, _ := emailPrivacy["enableImprovedEmailPrivacy"].(bool)
+	return enableImprovedEmailPrivacy
+}
+
+func flattenBlockingFunctions(v interface{}, d *schema.ResourceData, config *Config) interface{} {
+	blockingFunctions, ok := v.(map[string]interface{})
+	if !ok {
+		return []interface{}{}
+	}
+
+	triggers := make([]interface{}, 0)
+	if m, ok := blockingFunctions["triggers"].(map[string]interface{}); ok {
+		for eventType, raw := range m {
+			trigger, _ := raw.(map[string]interface{})
+			triggers = append(triggers, map[string]interface{}{
+				"event_type":   eventType,
+				"function_uri": trigger["functionUri"],
+			})
+		}
+	}
+
+	forwardInboundCredentials := make([]interface{}, 0)
+	if credentials, ok := blockingFunctions["forwardInboundCredentials"].(map[string]interface{}); ok {
+		idToken, _ := credentials["idToken"].(bool)
+		accessToken, _ := credentials["accessToken"].(bool)
+		refreshToken, _ := credentials["refreshToken"].(bool)
+		forwardInboundCredentials = append(forwardInboundCredentials, map[string]interface{}{
+			"id_token":      idToken,
+			"access_token":  accessToken,
+			"refresh_token": refreshToken,
+		})
+	} else if d != nil {
+		// the API omits the message when no credential is forwarded, keep an
+		// all-false block from config so it does not produce a diff
+		if l, ok := d.Get("blocking_functions.0.forward_inbound_credentials").([]interface{}); ok && len(l) > 0 {
+			forwardInboundCredentials = append(forwardInboundCredentials, map[string]interface{}{
+				"id_token":      false,
+				"access_token":  false,
+				"refresh_token": false,
+			})
+		}
+	}
+
+	return []interface{}{
+		map[string]interface{}{
+			"triggers":                    triggers,
+			"forward_inbound_credentials": forwardInboundCredentials,
+		},
+	}
 }
 
 func flattenAuthorizedDomains(v interface{}, d *schema.ResourceData, config *Config) interface{} {
@@ -179,14 +688,213 @@ func flattenAuthorizedDomains(v interface{}, d *schema.ResourceData, config *Con
 }
 
 func resourceFirebaseAuthConfigPatchEncoder(d *schema.ResourceData, meta interface{}, obj map[string]interface{}) (map[string]interface{}, error) {
-	emailProviderConfig := make(map[string]interface{})
-	emailProviderConfig["enabled"] = obj["email"]
-	emailProviderConfig["passwordRequired"] = obj["email"]
-	email := make(map[string]interface{})
-	email["email"] = emailProviderConfig
+	signIn := make(map[string]interface{})
+	if l, ok := obj["email"].([]interface{}); ok && len(l) > 0 && l[0] != nil {
+		email := l[0].(map[string]interface{})
+		signIn["email"] = map[string]interface{}{
+			"enabled":          email["enabled"],
+			"passwordRequired": email["password_required"],
+		}
+	}
+	if l, ok := obj["phone_number"].([]interface{}); ok && len(l) > 0 && l[0] != nil {
+		phoneNumber := l[0].(map[string]interface{})
+		signIn["phoneNumber"] = map[string]interface{}{
+			"enabled":          phoneNumber["enabled"],
+			"testPhoneNumbers": phoneNumber["test_phone_numbers"],
+		}
+	}
+	if l, ok := obj["anonymous"].([]interface{}); ok && len(l) > 0 && l[0] != nil {
+		anonymous := l[0].(map[string]interface{})
+		signIn["anonymous"] = map[string]interface{}{
+			"enabled": anonymous["enabled"],
+		}
+	}
+	signIn["allowDuplicateEmails"] = obj["allow_duplicate_emails"]
+
 	wrapper := make(map[string]interface{})
-	wrapper["signIn"] = email
+	wrapper["signIn"] = signIn
+	if l, ok := obj["mfa"].([]interface{}); ok && len(l) > 0 && l[0] != nil {
+		wrapper["mfa"] = expandMfa(l[0].(map[string]interface{}))
+	}
+	if l, ok := obj["password_policy"].([]interface{}); ok && len(l) > 0 && l[0] != nil {
+		wrapper["passwordPolicyConfig"] = expandPasswordPolicy(l[0].(map[string]interface{}))
+	}
+	wrapper["emailPrivacyConfig"] = map[string]interface{}{
+		"enableImprovedEmailPrivacy": obj["enable_improved_email_privacy"],
+	}
+	if l, ok := obj["blocking_functions"].([]interface{}); ok && len(l) > 0 && l[0] != nil {
+		blockingFunctions, err := expandBlockingFunctions(l[0].(map[string]interface{}))
+		if err != nil {
+			return nil, err
+		}
+		wrapper["blockingFunctions"] = blockingFunctions
+	}
 	wrapper["authorizedDomains"] = obj["authorized_domains"]
 
 	return wrapper, nil
 }
+
+func expandMfa(mfa map[string]interface{}) map[string]interface{} {
+	providerConfigs := make([]interface{}, 0)
+	for _, raw := range mfa["provider_config"].([]interface{}) {
+		providerConfig := raw.(map[string]interface{})
+		transformed := map[string]interface{}{
+			"state": providerConfig["state"],
+		}
+		if l := providerConfig["totp_provider_config"].([]interface{}); len(l) > 0 && l[0] != nil {
+			totp := l[0].(map[string]interface{})
+			totpProviderConfig := make(map[string]interface{})
+			// unset is read as 0, which would disable the window, so the API
+			// default is kept instead
+			if v, ok := totp["adjacent_intervals"].(int); ok && v > 0 {
+				totpProviderConfig["adjacentIntervals"] = v
+			}
+			transformed["totpProviderConfig"] = totpProviderConfig
+		}
+		providerConfigs = append(providerConfigs, transformed)
+	}
+
+	return map[string]interface{}{
+		"state":            mfa["state"],
+		"enabledProviders": mfa["enabled_providers"],
+		"providerConfigs":  providerConfigs,
+	}
+}
+
+// firebaseAuthConfigPasswordCharacterOptions maps the character requirements
+// of password_policy to the custom strength options of the config.
+var firebaseAuthConfigPasswordCharacterOptions = map[string]string{
+	"contains_lowercase_character":        "containsLowercaseCharacter",
+	"contains_uppercase_character":        "containsUppercaseCharacter",
+	"contains_numeric_character":          "containsNumericCharacter",
+	"contains_non_alphanumeric_character": "containsNonAlphanumericCharacter",
+}
+
+func expandPasswordPolicy(passwordPolicy map[string]interface{}) map[string]interface{} {
+	options := make(map[string]interface{})
+	// unset lengths fall back to the defaults of the API
+	if v, ok := passwordPolicy["min_password_length"].(int); ok && v > 0 {
+		options["minPasswordLength"] = v
+	}
+	if v, ok := passwordPolicy["max_password_length"].(int); ok && v > 0 {
+		options["maxPasswordLength"] = v
+	}
+	for field, option := range firebaseAuthConfigPasswordCharacterOptions {
+		options[option] = passwordPolicy[field]
+	}
+
+	return map[string]interface{}{
+		"passwordPolicyEnforcementState": passwordPolicy["enforcement_state"],
+		"forceUpgradeOnSignin":           passwordPolicy["force_upgrade_on_signin"],
+		"passwordPolicyVersions": []interface{}{
+			map[string]interface{}{
+				"customStrengthOptions": options,
+			},
+		},
+	}
+}
+
+func expandBlockingFunctions(blockingFunctions map[string]interface{}) (map[string]interface{}, error) {
+	triggers := make(map[string]interface{})
+	if set, ok := blockingFunctions["triggers"].(*schema.Set); ok {
+		for _, raw := range set.List() {
+			trigger := raw.(map[string]interface{})
+			eventType := trigger["event_type"].(string)
+			if _, ok := triggers[eventType]; ok {
+				return nil, fmt.Errorf("Error expanding blocking_functions: more than one trigger for event_type %q", eventType)
+			}
+			triggers[eventType] = map[string]interface{}{
+				"functionUri": trigger["function_uri"],
+			}
+		}
+	}
+
+	transformed := map[string]interface{}{
+		"triggers": triggers,
+	}
+	if l, ok := blockingFunctions["forward_inbound_credentials"].([]interface{}); ok && len(l) > 0 && l[0] != nil {
+		credentials := l[0].(map[string]interface{})
+		transformed["forwardInboundCredentials"] = map[string]interface{}{
+			"idToken":      credentials["id_token"],
+			"accessToken":  credentials["access_token"],
+			"refreshToken": credentials["refresh_token"],
+		}
+	}
+	return transformed, nil
+}
+
+func resourceFirebaseAuthConfigImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	config := meta.(*Config)
+	if err := parseImportId([]string{
+		"^projects/(?P<project>[^/]+)/config$",
+		"^(?P<project>[^/]+)$",
+	}, d, config); err != nil {
+		return nil, err
+	}
+
+	if err := resourceFirebaseAuthConfigRead(d, meta); err != nil {
+		return nil, err
+	}
+
+	if d.Id() == "" {
+		return nil, fmt.Errorf("Error importing AuthConfig: no config found in project %q", d.Get("project"))
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceFirebaseAuthConfigResourceV0 is the schema before email became a
+// block, when it was a single flag enabling email and password signin.
+func resourceFirebaseAuthConfigResourceV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"email": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"authorized_domains": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceFirebaseAuthConfigUpgradeV0(_ context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	log.Printf("[DEBUG] Attributes before migration: %#v", rawState)
+
+	// the flag set both enabled and passwordRequired
+	enabled, ok := rawState["email"].(bool)
+	if !ok {
+		rawState["email"] = []interface{}{}
+		return rawState, nil
+	}
+	rawState["email"] = []interface{}{
+		map[string]interface{}{
+			"enabled":           enabled,
+			"password_required": enabled,
+		},
+	}
+
+	log.Printf("[DEBUG] Attributes after migration: %#v", rawState)
+	return rawState, nil
+}
@@ -1,11 +1,13 @@
 package sidkik
 
 import (
+	"context"
 	"encoding/json"
 	"reflect"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -34,7 +36,46 @@ func TestAccFirebaseAuthConfig_config(t *testing.T) {
 func testAccFirebaseAuthConfig_config(context map[string]interface{}) string {
 	return Nprintf(`
 resource "sidkik_firebase_auth_config" "config" {
-	email = true
+	email {
+		enabled           = true
+		password_required = false
+	}
+	phone_number {
+		enabled = true
+		test_phone_numbers = {
+			"+1 555-555-1234" = "123456"
+		}
+	}
+	anonymous {
+		enabled = true
+	}
+	allow_duplicate_emails = false
+	mfa {
+		state             = "ENABLED"
+		enabled_providers = ["PHONE_SMS"]
+		provider_config {
+			state = "ENABLED"
+			totp_provider_config {
+				adjacent_intervals = 5
+			}
+		}
+	}
+	password_policy {
+		enforcement_state            = "ENFORCE"
+		min_password_length          = 12
+		contains_numeric_character   = true
+		contains_uppercase_character = true
+	}
+	enable_improved_email_privacy = true
+	blocking_functions {
+		triggers {
+			event_type   = "beforeCreate"
+			function_uri = "https://us-central1-my-account.cloudfunctions.net/beforeCreate"
+		}
+		forward_inbound_credentials {
+			id_token = true
+		}
+	}
 	authorized_domains =["my-account.sidkik.app", "admin-my-account.sidkik.app"]
 }
 `, context)
@@ -80,7 +121,7 @@ func Test_flattenEmail(t *testing.T) {
 	tests := []struct {
 		name string
 		args args
-		want bool
+		want interface{}
 	}{
 		{
 			name: "populated",
@@ -166,7 +207,12 @@ func Test_flattenEmail(t *testing.T) {
 				}
 				`,
 			},
-			want: true,
+			want: []interface{}{
+				map[string]interface{}{
+					"enabled":           true,
+					"password_required": true,
+				},
+			},
 		},
 		{
 			name: "empty",
@@ -248,7 +294,7 @@ func Test_flattenEmail(t *testing.T) {
 				}
 				`,
 			},
-			want: false,
+			want: []interface{}{},
 		},
 	}
 	for _, tt := range tests {
@@ -262,3 +308,496 @@ func Test_flattenEmail(t *testing.T) {
 		})
 	}
 }
+
+func TestFirebaseAuthConfigStateUpgradeV0(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		Attributes map[string]interface{}
+		Expected   map[string]interface{}
+	}{
+		"email enabled": {
+			Attributes: map[string]interface{}{
+				"email":   true,
+				"project": "my-project",
+			},
+			Expected: map[string]interface{}{
+				"email": []interface{}{
+					map[string]interface{}{
+						"enabled":           true,
+						"password_required": true,
+					},
+				},
+				"project": "my-project",
+			},
+		},
+		"email disabled": {
+			Attributes: map[string]interface{}{
+				"email": false,
+			},
+			Expected: map[string]interface{}{
+				"email": []interface{}{
+					map[string]interface{}{
+						"enabled":           false,
+						"password_required": false,
+					},
+				},
+			},
+		},
+		"email unset": {
+			Attributes: map[string]interface{}{
+				"project": "my-project",
+			},
+			Expected: map[string]interface{}{
+				"email":   []interface{}{},
+				"project": "my-project",
+			},
+		},
+	}
+	for tn, tc := range cases {
+		actual, err := resourceFirebaseAuthConfigUpgradeV0(context.Background(), tc.Attributes, nil)
+		if err != nil {
+			t.Fatalf("bad: %s, err: %#v", tn, err)
+		}
+		if !reflect.DeepEqual(actual, tc.Expected) {
+			t.Errorf("bad: %s, expected %#v, got %#v", tn, tc.Expected, actual)
+		}
+	}
+}
+
+func Test_resourceFirebaseAuthConfigUpdateMask(t *testing.T) {
+	cases := map[string]struct {
+		raw      map[string]interface{}
+		expected []string
+	}{
+		"nothing set": {
+			raw:      map[string]interface{}{},
+			expected: []string{},
+		},
+		"authorized domains unset": {
+			raw: map[string]interface{}{
+				"email": []interface{}{
+					map[string]interface{}{"enabled": true},
+				},
+				"anonymous": []interface{}{
+					map[string]interface{}{"enabled": false},
+				},
+				"allow_duplicate_emails": false,
+			},
+			expected: []string{"signIn.email", "signIn.anonymous", "signIn.allowDuplicateEmails"},
+		},
+		"all set": {
+			raw: map[string]interface{}{
+				"email": []interface{}{
+					map[string]interface{}{"enabled": true},
+				},
+				"phone_number": []interface{}{
+					map[string]interface{}{"enabled": true},
+				},
+				"anonymous": []interface{}{
+					map[string]interface{}{"enabled": true},
+				},
+				"allow_duplicate_emails": true,
+				"mfa": []interface{}{
+					map[string]interface{}{"state": "DISABLED"},
+				},
+				"enable_improved_email_privacy": true,
+				"authorized_domains":            []interface{}{"my-account.sidkik.app"},
+			},
+			expected: []string{"signIn.email", "signIn.phoneNumber", "signIn.anonymous", "signIn.allowDuplicateEmails", "mfa", "emailPrivacyConfig.enableImprovedEmailPrivacy", "authorizedDomains"},
+		},
+	}
+	for tn, tc := range cases {
+		d := schema.TestResourceDataRaw(t, resourceFirebaseAuthConfig().Schema, tc.raw)
+		if actual := resourceFirebaseAuthConfigUpdateMask(d); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("bad: %s, expected %q, got %q", tn, tc.expected, actual)
+		}
+	}
+}
+
+func Test_flattenMfa(t *testing.T) {
+	cases := map[string]struct {
+		response string
+		expected interface{}
+	}{
+		"sms and totp": {
+			response: `{
+				"state": "MANDATORY",
+				"enabledProviders": ["PHONE_SMS"],
+				"providerConfigs": [
+					{
+						"state": "ENABLED",
+						"totpProviderConfig": {
+							"adjacentIntervals": 5
+						}
+					}
+				]
+			}`,
+			expected: []interface{}{
+				map[string]interface{}{
+					"state":             "MANDATORY",
+					"enabled_providers": []interface{}{"PHONE_SMS"},
+					"provider_config": []interface{}{
+						map[string]interface{}{
+							"state": "ENABLED",
+							"totp_provider_config": []interface{}{
+								map[string]interface{}{
+									"adjacent_intervals": 5,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"disabled": {
+			response: `{
+				"state": "DISABLED"
+			}`,
+			expected: []interface{}{
+				map[string]interface{}{
+					"state":             "DISABLED",
+					"enabled_providers": nil,
+					"provider_config":   []interface{}{},
+				},
+			},
+		},
+		"unset": {
+			response: `null`,
+			expected: []interface{}{},
+		},
+	}
+	for tn, tc := range cases {
+		var mfa interface{}
+		if err := json.Unmarshal([]byte(tc.response), &mfa); err != nil {
+			t.Fatalf("bad: %s, err: %s", tn, err)
+		}
+		if actual := flattenMfa(mfa, nil, nil); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("bad: %s, expected %#v, got %#v", tn, tc.expected, actual)
+		}
+	}
+}
+
+func Test_expandMfa(t *testing.T) {
+	mfa := map[string]interface{}{
+		"state":             "ENABLED",
+		"enabled_providers": []interface{}{"PHONE_SMS"},
+		"provider_config": []interface{}{
+			map[string]interface{}{
+				"state": "ENABLED",
+				"totp_provider_config": []interface{}{
+					map[string]interface{}{
+						"adjacent_intervals": 3,
+					},
+				},
+			},
+		},
+	}
+	expected := map[string]interface{}{
+		"state":            "ENABLED",
+		"enabledProviders": []interface{}{"PHONE_SMS"},
+		"providerConfigs": []interface{}{
+			map[string]interface{}{
+				"state": "ENABLED",
+				"totpProviderConfig": map[string]interface{}{
+					"adjacentIntervals": 3,
+				},
+			},
+		},
+	}
+	if actual := expandMfa(mfa); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expandMfa() = %#v, want %#v", actual, expected)
+	}
+}
+
+func Test_resourceFirebaseAuthConfigPatchEncoder_totpAdjacentIntervals(t *testing.T) {
+	cases := map[string]struct {
+		totp     map[string]interface{}
+		expected map[string]interface{}
+	}{
+		"set": {
+			totp: map[string]interface{}{
+				"adjacent_intervals": 3,
+			},
+			expected: map[string]interface{}{
+				"adjacentIntervals": 3,
+			},
+		},
+		"unset": {
+			totp:     map[string]interface{}{},
+			expected: map[string]interface{}{},
+		},
+	}
+
+	for tn, tc := range cases {
+		d := schema.TestResourceDataRaw(t, resourceFirebaseAuthConfig().Schema, map[string]interface{}{
+			"mfa": []interface{}{
+				map[string]interface{}{
+					"state": "ENABLED",
+					"provider_config": []interface{}{
+						map[string]interface{}{
+							"state":                "ENABLED",
+							"totp_provider_config": []interface{}{tc.totp},
+						},
+					},
+				},
+			},
+		})
+		obj, err := resourceFirebaseAuthConfigPatchEncoder(d, nil, map[string]interface{}{
+			"mfa": d.Get("mfa"),
+		})
+		if err != nil {
+			t.Fatalf("bad: %s, err: %s", tn, err)
+		}
+		providerConfig := obj["mfa"].(map[string]interface{})["providerConfigs"].([]interface{})[0].(map[string]interface{})
+		if actual := providerConfig["totpProviderConfig"]; !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("bad: %s, expected %#v, got %#v", tn, tc.expected, actual)
+		}
+	}
+}
+
+func Test_flattenBlockingFunctions(t *testing.T) {
+	triggers := []interface{}{
+		map[string]interface{}{
+			"event_type":   "beforeCreate",
+			"function_uri": "https://us-central1-my-project.cloudfunctions.net/beforeCreate",
+		},
+	}
+	allFalse := map[string]interface{}{
+		"blocking_functions": []interface{}{
+			map[string]interface{}{
+				"forward_inbound_credentials": []interface{}{
+					map[string]interface{}{
+						"id_token":      false,
+						"access_token":  false,
+						"refresh_token": false,
+					},
+				},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		response string
+		raw      map[string]interface{}
+		expected []interface{}
+	}{
+		"forwarded credentials": {
+			response: `{
+				"triggers": {
+					"beforeCreate": {
+						"functionUri": "https://us-central1-my-project.cloudfunctions.net/beforeCreate",
+						"updateTime": "2026-01-01T00:00:00Z"
+					}
+				},
+				"forwardInboundCredentials": {
+					"idToken": true
+				}
+			}`,
+			expected: []interface{}{
+				map[string]interface{}{
+					"triggers": triggers,
+					"forward_inbound_credentials": []interface{}{
+						map[string]interface{}{
+							"id_token":      true,
+							"access_token":  false,
+							"refresh_token": false,
+						},
+					},
+				},
+			},
+		},
+		"omitted credentials not in config": {
+			response: `{
+				"triggers": {
+					"beforeCreate": {
+						"functionUri": "https://us-central1-my-project.cloudfunctions.net/beforeCreate"
+					}
+				}
+			}`,
+			raw: map[string]interface{}{},
+			expected: []interface{}{
+				map[string]interface{}{
+					"triggers":                    triggers,
+					"forward_inbound_credentials": []interface{}{},
+				},
+			},
+		},
+		"omitted credentials in config": {
+			response: `{
+				"triggers": {
+					"beforeCreate": {
+						"functionUri": "https://us-central1-my-project.cloudfunctions.net/beforeCreate"
+					}
+				}
+			}`,
+			raw: allFalse,
+			expected: []interface{}{
+				map[string]interface{}{
+					"triggers": triggers,
+					"forward_inbound_credentials": []interface{}{
+						map[string]interface{}{
+							"id_token":      false,
+							"access_token":  false,
+							"refresh_token": false,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for tn, tc := range cases {
+		var blockingFunctions interface{}
+		if err := json.Unmarshal([]byte(tc.response), &blockingFunctions); err != nil {
+			t.Fatal(err)
+		}
+		var d *schema.ResourceData
+		if tc.raw != nil {
+			d = schema.TestResourceDataRaw(t, resourceFirebaseAuthConfig().Schema, tc.raw)
+		}
+		if actual := flattenBlockingFunctions(blockingFunctions, d, nil); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("bad: %s, expected %#v, got %#v", tn, tc.expected, actual)
+		}
+	}
+}
+
+func Test_expandBlockingFunctions(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceFirebaseAuthConfig().Schema, map[string]interface{}{
+		"blocking_functions": []interface{}{
+			map[string]interface{}{
+				"triggers": []interface{}{
+					map[string]interface{}{
+						"event_type":   "beforeCreate",
+						"function_uri": "https://example.com/beforeCreate",
+					},
+					map[string]interface{}{
+						"event_type":   "beforeSignIn",
+						"function_uri": "https://example.com/beforeSignIn",
+					},
+				},
+				"forward_inbound_credentials": []interface{}{
+					map[string]interface{}{
+						"id_token": true,
+					},
+				},
+			},
+		},
+	})
+	expected := map[string]interface{}{
+		"triggers": map[string]interface{}{
+			"beforeCreate": map[string]interface{}{
+				"functionUri": "https://example.com/beforeCreate",
+			},
+			"beforeSignIn": map[string]interface{}{
+				"functionUri": "https://example.com/beforeSignIn",
+			},
+		},
+		"forwardInboundCredentials": map[string]interface{}{
+			"idToken":      true,
+			"accessToken":  false,
+			"refreshToken": false,
+		},
+	}
+
+	actual, err := expandBlockingFunctions(d.Get("blocking_functions").([]interface{})[0].(map[string]interface{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expandBlockingFunctions() = %#v, want %#v", actual, expected)
+	}
+	if mask := resourceFirebaseAuthConfigUpdateMask(d); !reflect.DeepEqual(mask, []string{"blockingFunctions.triggers", "blockingFunctions.forwardInboundCredentials"}) {
+		t.Errorf("resourceFirebaseAuthConfigUpdateMask() = %q", mask)
+	}
+
+	duplicate := schema.TestResourceDataRaw(t, resourceFirebaseAuthConfig().Schema, map[string]interface{}{
+		"blocking_functions": []interface{}{
+			map[string]interface{}{
+				"triggers": []interface{}{
+					map[string]interface{}{
+						"event_type":   "beforeCreate",
+						"function_uri": "https://example.com/a",
+					},
+					map[string]interface{}{
+						"event_type":   "beforeCreate",
+						"function_uri": "https://example.com/b",
+					},
+				},
+			},
+		},
+	})
+	if _, err := expandBlockingFunctions(duplicate.Get("blocking_functions").([]interface{})[0].(map[string]interface{})); err == nil {
+		t.Errorf("expandBlockingFunctions() expected an error for duplicate event types")
+	}
+}
+
+func Test_flattenPasswordPolicy(t *testing.T) {
+	response := `{
+		"passwordPolicyEnforcementState": "ENFORCE",
+		"passwordPolicyVersions": [
+			{
+				"customStrengthOptions": {
+					"minPasswordLength": 12,
+					"maxPasswordLength": 64,
+					"containsUppercaseCharacter": true,
+					"containsNumericCharacter": true
+				},
+				"schemaVersion": 1
+			}
+		],
+		"forceUpgradeOnSignin": true,
+		"lastUpdateTime": "2026-01-01T00:00:00Z"
+	}`
+	expected := []interface{}{
+		map[string]interface{}{
+			"enforcement_state":                   "ENFORCE",
+			"force_upgrade_on_signin":             true,
+			"min_password_length":                 12,
+			"max_password_length":                 64,
+			"contains_lowercase_character":        false,
+			"contains_uppercase_character":        true,
+			"contains_numeric_character":          true,
+			"contains_non_alphanumeric_character": false,
+		},
+	}
+
+	var passwordPolicy interface{}
+	if err := json.Unmarshal([]byte(response), &passwordPolicy); err != nil {
+		t.Fatal(err)
+	}
+	if actual := flattenPasswordPolicy(passwordPolicy, nil, nil); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("flattenPasswordPolicy() = %#v, want %#v", actual, expected)
+	}
+}
+
+func Test_expandPasswordPolicy(t *testing.T) {
+	passwordPolicy := map[string]interface{}{
+		"enforcement_state":                   "ENFORCE",
+		"force_upgrade_on_signin":             false,
+		"min_password_length":                 10,
+		"max_password_length":                 0,
+		"contains_lowercase_character":        true,
+		"contains_uppercase_character":        false,
+		"contains_numeric_character":          true,
+		"contains_non_alphanumeric_character": false,
+	}
+	expected := map[string]interface{}{
+		"passwordPolicyEnforcementState": "ENFORCE",
+		"forceUpgradeOnSignin":           false,
+		"passwordPolicyVersions": []interface{}{
+			map[string]interface{}{
+				"customStrengthOptions": map[string]interface{}{
+					"minPasswordLength":                10,
+					"containsLowercaseCharacter":       true,
+					"containsUppercaseCharacter":       false,
+					"containsNumericCharacter":         true,
+					"containsNonAlphanumericCharacter": false,
+				},
+			},
+		},
+	}
+	if actual := expandPasswordPolicy(passwordPolicy); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expandPasswordPolicy() = %#v, want %#v", actual, expected)
+	}
+}
@@ -0,0 +1,245 @@
+package sidkik
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"google.golang.org/api/googleapi"
+)
+
+func resourceFirebaseDatabaseRules() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceFirebaseDatabaseRulesCreate,
+		Read:   resourceFirebaseDatabaseRulesRead,
+		Update: resourceFirebaseDatabaseRulesUpdate,
+		Delete: resourceFirebaseDatabaseRulesDelete,
+
+		Importer: &schema.ResourceImporter{
+			State: resourceFirebaseDatabaseRulesImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(4 * time.Minute),
+			Update: schema.DefaultTimeout(4 * time.Minute),
+			Delete: schema.DefaultTimeout(4 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"instance": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: `Realtime Database instance, e.g. my-project-default-rtdb, or the host of a regional instance, e.g. my-instance.europe-west1.firebasedatabase.app`,
+			},
+			"rules": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateFunc:     validation.StringIsJSON,
+				DiffSuppressFunc: structure.SuppressJsonDiff,
+				Description:      `JSON encoded security rules of the database, e.g. {"rules": {".read": false, ".write": false}}`,
+			},
+			"on_destroy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "keep",
+				ValidateFunc: validation.StringInSlice([]string{"keep", "lock_down"}, false),
+				Description:  `What happens to the rules on destroy. keep leaves the rules live and lock_down replaces them with rules that deny all access`,
+			},
+		},
+		UseJSONNumber: true,
+	}
+}
+
+// firebaseDatabaseLockDownRules are set by on_destroy = "lock_down".
+const firebaseDatabaseLockDownRules = `{"rules": {".read": false, ".write": false}}`
+
+func resourceFirebaseDatabaseRulesCreate(d *schema.ResourceData, meta interface{}) error {
+	if err := resourceFirebaseDatabaseRulesPut(d, meta, d.Get("rules").(string), d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
+
+	d.SetId(d.Get("instance").(string))
+
+	return resourceFirebaseDatabaseRulesRead(d, meta)
+}
+
+func resourceFirebaseDatabaseRulesRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	url, err := firebaseDatabaseRulesUrl(d, config)
+	if err != nil {
+		return err
+	}
+
+	res, err := sendFirebaseDatabaseRequest(config, "GET", url, userAgent, "", DefaultRequestTimeout)
+	if err != nil {
+		return handleNotFoundError(err, d, fmt.Sprintf("FirebaseDatabaseRules %q", d.Id()))
+	}
+
+	rules, err := flattenFirebaseDatabaseRules(res)
+	if err != nil {
+		log.Printf("[WARN] Cannot normalize rules of Realtime Database %q: %s", d.Id(), err)
+		rules = res
+	}
+
+	if err := d.Set("instance", d.Id()); err != nil {
+		return fmt.Errorf("Error reading FirebaseDatabaseRules: %s", err)
+	}
+	if err := d.Set("rules", rules); err != nil {
+		return fmt.Errorf("Error reading FirebaseDatabaseRules: %s", err)
+	}
+
+	return nil
+}
+
+func resourceFirebaseDatabaseRulesUpdate(d *schema.ResourceData, meta interface{}) error {
+	if d.HasChange("rules") {
+		if err := resourceFirebaseDatabaseRulesPut(d, meta, d.Get("rules").(string), d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return err
+		}
+	}
+
+	return resourceFirebaseDatabaseRulesRead(d, meta)
+}
+
+func resourceFirebaseDatabaseRulesDelete(d *schema.ResourceData, meta interface{}) error {
+	if d.Get("on_destroy").(string) != "lock_down" {
+		log.Printf("[INFO] Not deleting rules of Realtime Database %q - a database always has rules", d.Id())
+		return nil
+	}
+
+	log.Printf("[INFO] Locking down rules of Realtime Database %q", d.Id())
+	return resourceFirebaseDatabaseRulesPut(d, meta, firebaseDatabaseLockDownRules, d.Timeout(schema.TimeoutDelete))
+}
+
+func resourceFirebaseDatabaseRulesImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if err := d.Set("instance", d.Id()); err != nil {
+		return nil, fmt.Errorf("Error setting instance: %s", err)
+	}
+
+	// Explicitly set virtual fields to default values on import
+	if err := d.Set("on_destroy", "keep"); err != nil {
+		return nil, fmt.Errorf("Error setting on_destroy: %s", err)
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceFirebaseDatabaseRulesPut(d *schema.ResourceData, meta interface{}, rules string, timeout time.Duration) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	url, err := firebaseDatabaseRulesUrl(d, config)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Updating rules of Realtime Database %q: %s", d.Get("instance"), rules)
+
+	if _, err := sendFirebaseDatabaseRequest(config, "PUT", url, userAgent, rules, timeout); err != nil {
+		return fmt.Errorf("Error updating FirebaseDatabaseRules: %s", err)
+	}
+
+	log.Printf("[DEBUG] Finished updating rules of Realtime Database %q", d.Get("instance"))
+	return nil
+}
+
+// firebaseDatabaseRulesUrl returns the rules endpoint of the instance. A
+// plain instance name is expanded with the FirebaseDatabase base path, while an
+// instance containing a dot is used as the host of a regional database unless
+// the base path was overridden, e.g. to point at the emulator.
+func firebaseDatabaseRulesUrl(d TerraformResourceData, config *Config) (string, error) {
+	instance := d.Get("instance").(string)
+	if strings.Contains(instance, ".") && config.FirebaseDatabaseBasePath == DefaultBasePaths[FirebaseDatabaseBasePathKey] {
+		return fmt.Sprintf("https://%s/.settings/rules.json", instance), nil
+	}
+	return replaceVars(d, config, "{{FirebaseDatabaseBasePath}}.settings/rules.json")
+}
+
+// sendFirebaseDatabaseRequest sends a raw request to the Realtime Database
+// REST API with the Realtime Database client of config. Rules are sent and
+// returned verbatim since they may contain comments, which the JSON helpers in
+// sendRequest cannot handle.
+func sendFirebaseDatabaseRequest(config *Config, method, url, userAgent, body string, timeout time.Duration) (string, error) {
+	var res *http.Response
+	err := retryTimeDuration(
+		func() error {
+			req, err := http.NewRequest(method, url, strings.NewReader(body))
+			if err != nil {
+				return err
+			}
+			req.Header.Set("User-Agent", userAgent)
+			req.Header.Set("Content-Type", "application/json")
+
+			res, err = config.firebaseDatabaseClient.Do(req)
+			if err != nil {
+				return err
+			}
+
+			if err := googleapi.CheckResponse(res); err != nil {
+				googleapi.CloseBody(res)
+				return err
+			}
+
+			return nil
+		},
+		timeout,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	defer googleapi.CloseBody(res)
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// flattenFirebaseDatabaseRules strips comments from the rules returned by the
+// API and normalizes the remaining JSON.
+func flattenFirebaseDatabaseRules(v string) (string, error) {
+	tokens, err := lexFirebaseRules(v)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, tok := range firebaseRulesSignificantTokens(tokens) {
+		sb.WriteString(tok.text)
+	}
+
+	var rules interface{}
+	dec := json.NewDecoder(strings.NewReader(sb.String()))
+	dec.UseNumber()
+	if err := dec.Decode(&rules); err != nil {
+		return "", err
+	}
+
+	// rules are full of comparisons, keep them readable in state
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(rules); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
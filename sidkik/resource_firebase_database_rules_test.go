@@ -0,0 +1,132 @@
+package sidkik
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAccFirebaseDatabaseRules_update(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{
+		"instance": getTestProjectFromEnv() + "-default-rtdb",
+	}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseDatabaseRules_rules(context),
+			},
+			{
+				ResourceName:      "sidkik_firebase_database_rules.rules",
+				ImportState:       true,
+				ImportStateId:     context["instance"].(string),
+				ImportStateVerify: true,
+			},
+			{
+				Config: testAccFirebaseDatabaseRules_rulesUpdated(context),
+			},
+		},
+	})
+}
+
+func testAccFirebaseDatabaseRules_rules(context map[string]interface{}) string {
+	return Nprintf(`
+resource "sidkik_firebase_database_rules" "rules" {
+	instance = "%{instance}"
+	rules = jsonencode({
+		rules = {
+			".read"  = false
+			".write" = false
+		}
+	})
+}
+`, context)
+}
+
+func testAccFirebaseDatabaseRules_rulesUpdated(context map[string]interface{}) string {
+	return Nprintf(`
+resource "sidkik_firebase_database_rules" "rules" {
+	instance = "%{instance}"
+	rules = jsonencode({
+		rules = {
+			users = {
+				"$uid" = {
+					".read"  = "auth != null && auth.uid == $uid"
+					".write" = "auth != null && auth.uid == $uid"
+				}
+			}
+		}
+	})
+}
+`, context)
+}
+
+func Test_flattenFirebaseDatabaseRules(t *testing.T) {
+	res := `{
+  /* Visit https://firebase.google.com/docs/database/security to learn more */
+  "rules": {
+    // signed in users only
+    ".read": "auth != null", // trailing comment
+    ".write": false,
+    "limits": { ".validate": "newData.val() >= -1.5e3" }
+  }
+}`
+
+	want := `{"rules":{".read":"auth != null",".write":false,"limits":{".validate":"newData.val() >= -1.5e3"}}}`
+
+	got, err := flattenFirebaseDatabaseRules(res)
+	if err != nil {
+		t.Fatalf("flattenFirebaseDatabaseRules() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("flattenFirebaseDatabaseRules() = %s, want %s", got, want)
+	}
+}
+
+func Test_firebaseDatabaseRulesUrl(t *testing.T) {
+	cases := map[string]struct {
+		instance string
+		basePath string
+		expected string
+	}{
+		"instance name": {
+			instance: "my-project-default-rtdb",
+			basePath: DefaultBasePaths[FirebaseDatabaseBasePathKey],
+			expected: "https://my-project-default-rtdb.firebaseio.com/.settings/rules.json",
+		},
+		"regional host": {
+			instance: "my-instance.europe-west1.firebasedatabase.app",
+			basePath: DefaultBasePaths[FirebaseDatabaseBasePathKey],
+			expected: "https://my-instance.europe-west1.firebasedatabase.app/.settings/rules.json",
+		},
+		"custom endpoint per instance": {
+			instance: "my-project-default-rtdb",
+			basePath: "https://{{instance}}.example.com/",
+			expected: "https://my-project-default-rtdb.example.com/.settings/rules.json",
+		},
+		"custom endpoint overrides regional host": {
+			instance: "my-instance.europe-west1.firebasedatabase.app",
+			basePath: "http://localhost:9000/",
+			expected: "http://localhost:9000/.settings/rules.json",
+		},
+	}
+
+	for tn, tc := range cases {
+		d := schema.TestResourceDataRaw(t, resourceFirebaseDatabaseRules().Schema, map[string]interface{}{
+			"instance": tc.instance,
+		})
+		got, err := firebaseDatabaseRulesUrl(d, &Config{FirebaseDatabaseBasePath: tc.basePath})
+		if err != nil {
+			t.Errorf("bad: %s, unexpected error: %s", tn, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("bad: %s, expected %s, got %s", tn, tc.expected, got)
+		}
+	}
+}
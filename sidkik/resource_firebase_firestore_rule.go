@@ -3,23 +3,22 @@ package sidkik
 import (
 	"fmt"
 	"log"
-	"reflect"
-	"strings"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func resourceFirebaseFirestoreRule() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceFirebaseFirestoreRuleCreate,
-		Read:   resourceFirebaseFirestoreRuleRead,
-		// Update: resourceFirebaseFirestoreRuleCreate,
-		Delete: resourceFirebaseFirestoreRuleDelete,
+		CreateContext: firebaseRulesLintWarnings("firestore.rules", resourceFirebaseFirestoreRuleCreate),
+		Read:          resourceFirebaseFirestoreRuleRead,
+		UpdateContext: firebaseRulesLintWarnings("firestore.rules", resourceFirebaseFirestoreRuleUpdate),
+		Delete:        resourceFirebaseFirestoreRuleDelete,
 
-		// Importer: &schema.ResourceImporter{
-		// 	State: resourceComputeHealthCheckImport,
-		// },
+		Importer: &schema.ResourceImporter{
+			State: resourceFirebaseFirestoreRuleImport,
+		},
 
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(4 * time.Minute),
@@ -27,22 +26,55 @@ func resourceFirebaseFirestoreRule() *schema.Resource {
 			Delete: schema.DefaultTimeout(4 * time.Minute),
 		},
 
-		// CustomizeDiff: rulesCustomizeDiff,
+		CustomizeDiff: customdiff.All(
+			firebaseRulesSourceSha256CustomizeDiff("firestore.rules", false),
+			firebaseRulesetNameCustomizeDiff,
+			firebaseRulesCompileCustomizeDiff("firestore.rules"),
+			firebaseRulesLintCustomizeDiff("firestore.rules"),
+		),
 
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:        schema.TypeString,
 				Optional:    true,
 				Computed:    true,
-				ForceNew:    true,
 				Description: `name of the firestore rule`,
 			},
 			"rule": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Computed:    true,
-				ForceNew:    true,
-				Description: `Source for the firestore rule. Provided as a string with the correct rules schems`,
+				Type:             schema.TypeString,
+				Optional:         true,
+				ExactlyOneOf:     []string{"rule", "rule_file", "files"},
+				Description:      `Source for the firestore rule. Provided as a string with the correct rules schems. Changes to whitespace or comments alone do not produce a diff`,
+				DiffSuppressFunc: firebaseRulesSourceDiffSuppress,
+				ValidateFunc:     validateFirebaseRulesSource,
+			},
+			"rule_file": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"rule", "rule_file", "files"},
+				Description:  `Path to a file holding the source for the firestore rule, as an alternative to rule. Only the path and source_sha256 are kept in state`,
+			},
+			"files": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				ExactlyOneOf: []string{"rule", "rule_file", "files"},
+				Description:  `Source files of the firestore rule, as an alternative to rule. Files are released in order as a single ruleset`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: `Name of the source file`,
+						},
+						"content": {
+							Type:             schema.TypeString,
+							Required:         true,
+							Description:      `Content of the source file`,
+							DiffSuppressFunc: firebaseRulesSourceDiffSuppress,
+							ValidateFunc:     validateFirebaseRulesSource,
+						},
+					},
+				},
 			},
 			"project": {
 				Type:     schema.TypeString,
@@ -50,6 +82,27 @@ func resourceFirebaseFirestoreRule() *schema.Resource {
 				Computed: true,
 				ForceNew: true,
 			},
+			"test_case":       firebaseRulesTestCaseSchema(),
+			"retain_rulesets": firebaseRulesRetainRulesetsSchema(),
+			"on_destroy":      firebaseRulesOnDestroySchema(),
+			"source_sha256": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `SHA-256 of the released source, ignoring whitespace and comments. Changes when the source is edited in config, in rule_file or outside of Terraform`,
+			},
+			"previous_ruleset_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `The ruleset that was released before this resource was created`,
+			},
+			"created_release": firebaseRulesCreatedReleaseSchema(),
+			"database": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "(default)",
+				Description: `The Firestore database the rule is released to. Defaults to the (default) database`,
+			},
 		},
 		UseJSONNumber: true,
 	}
@@ -62,18 +115,13 @@ func resourceFirebaseFirestoreRuleRead(d *schema.ResourceData, meta interface{})
 	if err != nil {
 		return err
 	}
-	urlReleases, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}projects/{{project}}/releases")
-	if err != nil {
-		return err
-	}
-
 	project, err := getProject(d, config)
 	if err != nil {
 		return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
 	}
 
 	// grab the existing rules
-	res, err := sendRequest(config, "GET", project, urlReleases, userAgent, nil)
+	ruleSets, err := listFirebaseRules(d, config, userAgent, "releases")
 
 	if err != nil {
 		return handleNotFoundError(err, d, fmt.Sprintf("FirebaseRules %q", d.Id()))
@@ -83,19 +131,30 @@ func resourceFirebaseFirestoreRuleRead(d *schema.ResourceData, meta interface{})
 		return fmt.Errorf("Error reading FirebaseRules: %s", err)
 	}
 
-	if res["releases"] == nil {
+	if len(ruleSets) == 0 {
 		log.Printf("[INFO] No releases yet: %#v", d)
 		return nil
 	}
 
-	ruleSets := res["releases"].([]interface{})
+	releaseName, err := replaceVars(d, config, firebaseFirestoreReleaseTemplate(d))
+	if err != nil {
+		return err
+	}
+
+	if err := d.Set("name", flattenFirebaseRuleNameForRelease(ruleSets, releaseName, d, config)); err != nil {
+		return fmt.Errorf("Error reading FirebaseRules: %s", err)
+	}
 
-	if err := d.Set("name", flattenFirebaseRuleName(ruleSets, "cloud.firestore", d, config)); err != nil {
+	if err := d.Set("database", flattenFirebaseFirestoreRuleDatabase(d.Get("database"), d, config)); err != nil {
 		return fmt.Errorf("Error reading FirebaseRules: %s", err)
 	}
 
 	// Set the ID now
 	d.SetId(d.Get("name").(string))
+	if d.Id() == "" {
+		log.Printf("[INFO] No %s release found: %#v", releaseName, d)
+		return nil
+	}
 
 	// now grab the actual rule text
 
@@ -111,7 +170,7 @@ func resourceFirebaseFirestoreRuleRead(d *schema.ResourceData, meta interface{})
 		return handleNotFoundError(err, d, fmt.Sprintf("FirebaseRules %q", d.Id()))
 	}
 
-	if err := d.Set("rule", flattenRuleFromSource(resFirestoreRule["source"], d, config)); err != nil {
+	if err := setFirebaseRulesSource(d, resFirestoreRule["source"]); err != nil {
 		return fmt.Errorf("Error reading FirebaseRules: %s", err)
 	}
 
@@ -121,119 +180,124 @@ func resourceFirebaseFirestoreRuleRead(d *schema.ResourceData, meta interface{})
 }
 
 func resourceFirebaseFirestoreRuleDelete(d *schema.ResourceData, meta interface{}) error {
-	// config := meta.(*Config)
-	// userAgent, err := generateUserAgentString(d, config.userAgent)
-	// if err != nil {
-	// 	return err
-	// }
-	// url, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}{{name}}")
-	// if err != nil {
-	// 	return err
-	// }
-
-	// project, err := getProject(d, config)
-	// if err != nil {
-	// 	return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
-	// }
-
-	// res, err := sendRequestWithTimeout(config, "DELETE", project, url, userAgent, nil, d.Timeout(schema.TimeoutDelete))
-	// if err != nil {
-	// 	return handleNotFoundError(err, d, "Rule")
-	// }
-
-	// log.Printf("[DEBUG] Finished deleting Rule %q: %#v", d.Id(), res)
-	log.Printf("[INFO] Not deleting rule - new rule had been made the latest release")
-	return nil
+	return destroyFirebaseRulesRelease(d, meta, firebaseFirestoreReleaseTemplate(d), "firestore.rules", firebaseFirestoreLockDownRule)
 }
 
 func resourceFirebaseFirestoreRuleCreate(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[DEBUG] Starting Firestore Rule Create: %#v", d)
-	config := meta.(*Config)
-	userAgent, err := generateUserAgentString(d, config.userAgent)
+
+	previous, err := getFirebaseReleaseRulesetName(d, meta, firebaseFirestoreReleaseTemplate(d))
 	if err != nil {
 		return err
 	}
-	url, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}projects/{{project}}/rulesets")
-	if err != nil {
-		return err
+	if err := d.Set("previous_ruleset_name", previous); err != nil {
+		return fmt.Errorf("Error setting previous_ruleset_name: %s", err)
+	}
+	if err := d.Set("created_release", previous == ""); err != nil {
+		return fmt.Errorf("Error setting created_release: %s", err)
 	}
 
-	project, err := getProject(d, config)
-	if err != nil {
-		return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	if err := resourceFirebaseFirestoreRuleRelease(d, meta, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
 	}
 
-	log.Printf("[DEBUG] Building new Firebase Rule: %#v", d)
+	return resourceFirebaseFirestoreRuleRead(d, meta)
+}
 
-	obj := make(map[string]interface{})
+func resourceFirebaseFirestoreRuleUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] Starting Firestore Rule Update: %#v", d)
 
-	if !isEmptyValue(reflect.ValueOf(d.Get("rule"))) {
-		obj["rule"] = d.Get("rule")
-		obj["ruleType"] = "firestore.rules"
+	if d.HasChange("rule") || d.HasChange("files") || d.HasChange("source_sha256") {
+		if err := resourceFirebaseFirestoreRuleRelease(d, meta, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return err
+		}
 	}
 
-	obj, err = resourceFirebaseRuleEncoder(d, meta, obj)
-	if err != nil {
-		return err
-	}
+	return resourceFirebaseFirestoreRuleRead(d, meta)
+}
 
-	log.Printf("[DEBUG] Creating new Firebase Rule: %#v", obj)
+// resourceFirebaseFirestoreRuleRelease creates a ruleset from the current rule
+// source and makes it the live release of the resource's database.
+func resourceFirebaseFirestoreRuleRelease(d *schema.ResourceData, meta interface{}, timeout time.Duration) error {
+	log.Printf("[DEBUG] Building new Firebase Rule: %#v", d)
 
-	res, err := sendRequestWithTimeout(config, "POST", project, url, userAgent, obj, d.Timeout(schema.TimeoutCreate))
+	rulesetName, err := createFirebaseRuleset(d, meta, "firestore.rules", firebaseFirestoreReleaseTemplate(d), timeout)
 	if err != nil {
-		return fmt.Errorf("Error creating Firebase Rule: %s", err)
+		return err
 	}
 
 	// Store the ID now
-	d.SetId(res["name"].(string))
-	d.Set("name", res["name"].(string))
-
-	log.Printf("[DEBUG] Finished creating Firebase Rule %q: %#v", d.Id(), res)
+	d.SetId(rulesetName)
+	d.Set("name", rulesetName)
 
 	log.Printf("[DEBUG] Updating release with new Firebase Rule: %#v", d)
 
-	urlRelease, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}projects/{{project}}/releases/cloud.firestore")
-	if err != nil {
+	if err := releaseFirebaseRuleset(d, meta, firebaseFirestoreReleaseTemplate(d), rulesetName, timeout); err != nil {
 		return err
 	}
 
-	releaseObj := make(map[string]interface{})
-	releaseName, err := replaceVars(d, config, "projects/{{project}}/releases/cloud.firestore")
-	releaseObj["name"] = releaseName
-	releaseObj["ruleName"] = res["name"].(string)
+	log.Printf("[DEBUG] Finished release with new Firebase Rule: %#v", d)
 
-	releaseObj, err = resourceFirebaseReleasePatchEncoder(d, meta, releaseObj)
-	if err != nil {
-		log.Printf("[ERROR] Cannot convert release to encoded obj: %v", err)
-		return err
+	deleteExpiredFirebaseRulesets(d, meta.(*Config), firebaseFirestoreReleaseTemplate(d), rulesetName, timeout)
+
+	return nil
+}
+
+func resourceFirebaseFirestoreRuleImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	config := meta.(*Config)
+	if err := parseImportId([]string{
+		"^projects/(?P<project>[^/]+)/releases/cloud\\.firestore/(?P<database>[^/]+)$",
+		"^projects/(?P<project>[^/]+)/releases/cloud\\.firestore$",
+		"^(?P<project>[^/]+)/(?P<database>[^/]+)$",
+		"^(?P<project>[^/]+)$",
+	}, d, config); err != nil {
+		return nil, err
 	}
 
-	log.Printf("[DEBUG] release obj: %v", releaseObj)
-	_, err = sendRequestWithTimeout(config, "PATCH", project, urlRelease, userAgent, releaseObj, d.Timeout(schema.TimeoutCreate))
-	if err != nil {
-		// release doesn't exist - try to create
-		if strings.Contains(err.Error(), "404") {
-			urlCreateRelease, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}projects/{{project}}/releases")
-			if err != nil {
-				return err
-			}
-			releasePostObj := make(map[string]interface{})
-			releaseName, err := replaceVars(d, config, "projects/{{project}}/releases/cloud.firestore")
-			releasePostObj["name"] = releaseName
-			releasePostObj["ruleName"] = res["name"].(string)
-			releasePostObj, err = resourceFirebaseReleasePostEncoder(d, meta, releasePostObj)
-			if err != nil {
-				log.Printf("[ERROR] Cannot convert release to encoded obj: %v", err)
-				return err
-			}
-			sendRequestWithTimeout(config, "POST", project, urlCreateRelease, userAgent, releasePostObj, d.Timeout(schema.TimeoutCreate))
-		} else {
-			return fmt.Errorf("Error creating Firebase Release: %s", err)
-		}
+	// Explicitly set virtual fields to default values on import
+	if err := d.Set("on_destroy", "keep"); err != nil {
+		return nil, fmt.Errorf("Error setting on_destroy: %s", err)
+	}
+	if err := d.Set("created_release", false); err != nil {
+		return nil, fmt.Errorf("Error setting created_release: %s", err)
+	}
 
+	if err := resourceFirebaseFirestoreRuleRead(d, meta); err != nil {
+		return nil, err
 	}
 
-	log.Printf("[DEBUG] Finished release with new Firebase Rule: %#v", d)
+	if d.Get("name").(string) == "" {
+		return nil, fmt.Errorf("Error importing FirebaseRules: no cloud.firestore release found for database %q in project %q", d.Get("database"), d.Get("project"))
+	}
 
-	return resourceFirebaseFirestoreRuleRead(d, meta)
+	return []*schema.ResourceData{d}, nil
+}
+
+// firebaseFirestoreLockDownRule is released by on_destroy = "lock_down".
+const firebaseFirestoreLockDownRule = `rules_version = '2';
+service cloud.firestore {
+  match /databases/{database}/documents {
+    match /{document=**} {
+      allow read, write: if false;
+    }
+  }
+}
+`
+
+// firebaseFirestoreReleaseTemplate returns the release name template for the
+// resource's database. The (default) database is released as cloud.firestore,
+// named databases as cloud.firestore/<database>.
+func firebaseFirestoreReleaseTemplate(d TerraformResourceData) string {
+	database, _ := d.Get("database").(string)
+	if database == "" || database == "(default)" {
+		return "projects/{{project}}/releases/cloud.firestore"
+	}
+	return "projects/{{project}}/releases/cloud.firestore/" + database
+}
+
+func flattenFirebaseFirestoreRuleDatabase(v interface{}, d *schema.ResourceData, config *Config) interface{} {
+	if v == nil || v.(string) == "" {
+		return "(default)"
+	}
+	return v
 }
@@ -1,6 +1,9 @@
 package sidkik
 
 import (
+	"context"
+	"encoding/json"
+	"reflect"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -21,9 +24,164 @@ func TestAccFirebaseFirestoreRule_rule(t *testing.T) {
 				Config: testAccFirebaseFirestoreRule_rule(context),
 			},
 			{
-				ResourceName:      "sidkik_firebase_firestore_rule.rule",
-				ImportState:       true,
-				ImportStateVerify: true,
+				ResourceName:            "sidkik_firebase_firestore_rule.rule",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"previous_ruleset_name", "created_release"},
+			},
+		},
+	})
+}
+
+func TestAccFirebaseFirestoreRule_update(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckFirebaseFirestoreRuleDestroyProducer(t),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseFirestoreRule_rule(context),
+			},
+			{
+				Config: testAccFirebaseFirestoreRule_ruleUpdated(context),
+			},
+		},
+	})
+}
+
+func TestAccFirebaseFirestoreRule_importAfterUpdate(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckFirebaseFirestoreRuleDestroyProducer(t),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseFirestoreRule_rule(context),
+			},
+			{
+				Config: testAccFirebaseFirestoreRule_ruleUpdated(context),
+			},
+			{
+				ResourceName:            "sidkik_firebase_firestore_rule.rule",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"previous_ruleset_name", "created_release"},
+			},
+		},
+	})
+}
+
+func TestAccFirebaseFirestoreRule_database(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{
+		"database": "tf-test-db",
+	}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckFirebaseFirestoreRuleDestroyProducer(t),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseFirestoreRule_database(context),
+			},
+			{
+				ResourceName:            "sidkik_firebase_firestore_rule.rule",
+				ImportState:             true,
+				ImportStateId:           "projects/" + getTestProjectFromEnv() + "/releases/cloud.firestore/tf-test-db",
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"previous_ruleset_name", "created_release"},
+			},
+		},
+	})
+}
+
+func TestAccFirebaseFirestoreRule_testCases(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckFirebaseFirestoreRuleDestroyProducer(t),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseFirestoreRule_testCases(context),
+			},
+		},
+	})
+}
+
+func TestAccFirebaseFirestoreRule_files(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckFirebaseFirestoreRuleDestroyProducer(t),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseFirestoreRule_files(context),
+			},
+			{
+				ResourceName:            "sidkik_firebase_firestore_rule.rule",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"previous_ruleset_name", "created_release"},
+			},
+		},
+	})
+}
+
+func TestAccFirebaseFirestoreRule_onDestroy(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{
+		"on_destroy": "lock_down",
+	}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckFirebaseFirestoreRuleDestroyProducer(t),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseFirestoreRule_onDestroy(context),
+			},
+		},
+	})
+}
+
+func TestAccFirebaseFirestoreRule_ruleFile(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{
+		"rule_file": "./test-fixtures/firestore.rules",
+	}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckFirebaseFirestoreRuleDestroyProducer(t),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseFirestoreRule_ruleFile(context),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("sidkik_firebase_firestore_rule.rule", "source_sha256"),
+					resource.TestCheckResourceAttr("sidkik_firebase_firestore_rule.rule", "rule", ""),
+				),
 			},
 		},
 	})
@@ -47,6 +205,134 @@ EOT
 `, context)
 }
 
+func testAccFirebaseFirestoreRule_ruleUpdated(context map[string]interface{}) string {
+	return Nprintf(`
+resource "sidkik_firebase_firestore_rule" "rule" {
+	rule = <<EOT
+rules_version = '2';
+service cloud.firestore {
+	match /databases/{database}/documents {
+		match /{document=**} {
+			allow read: if request.auth != null;
+			allow write: if false;
+		}
+	}
+}
+EOT
+}
+`, context)
+}
+
+func testAccFirebaseFirestoreRule_database(context map[string]interface{}) string {
+	return Nprintf(`
+resource "sidkik_firebase_firestore_rule" "rule" {
+	database = "%{database}"
+	rule = <<EOT
+rules_version = '2';
+service cloud.firestore {
+	match /databases/{database}/documents {
+		match /{document=**} {
+			allow read, write: if false;
+		}
+	}
+}
+EOT
+}
+`, context)
+}
+
+func testAccFirebaseFirestoreRule_testCases(context map[string]interface{}) string {
+	return Nprintf(`
+resource "sidkik_firebase_firestore_rule" "rule" {
+	rule = <<EOT
+rules_version = '2';
+service cloud.firestore {
+	match /databases/{database}/documents {
+		match /users/{userId} {
+			allow read, write: if request.auth != null && request.auth.uid == userId;
+		}
+	}
+}
+EOT
+
+	test_case {
+		name        = "owner can read"
+		expectation = "ALLOW"
+		request = jsonencode({
+			auth   = { uid = "alice" }
+			method = "get"
+			path   = "/databases/(default)/documents/users/alice"
+		})
+	}
+
+	test_case {
+		name        = "others cannot read"
+		expectation = "DENY"
+		request = jsonencode({
+			auth   = { uid = "bob" }
+			method = "get"
+			path   = "/databases/(default)/documents/users/alice"
+		})
+	}
+}
+`, context)
+}
+
+func testAccFirebaseFirestoreRule_files(context map[string]interface{}) string {
+	return Nprintf(`
+resource "sidkik_firebase_firestore_rule" "rule" {
+	files {
+		name    = "helpers.rules"
+		content = <<EOT
+function isSignedIn() {
+	return request.auth != null;
+}
+EOT
+	}
+
+	files {
+		name    = "firestore.rules"
+		content = <<EOT
+rules_version = '2';
+service cloud.firestore {
+	match /databases/{database}/documents {
+		match /{document=**} {
+			allow read: if isSignedIn();
+		}
+	}
+}
+EOT
+	}
+}
+`, context)
+}
+
+func testAccFirebaseFirestoreRule_onDestroy(context map[string]interface{}) string {
+	return Nprintf(`
+resource "sidkik_firebase_firestore_rule" "rule" {
+	on_destroy = "%{on_destroy}"
+	rule = <<EOT
+rules_version = '2';
+service cloud.firestore {
+	match /databases/{database}/documents {
+		match /{document=**} {
+			allow read: if request.auth != null;
+		}
+	}
+}
+EOT
+}
+`, context)
+}
+
+func testAccFirebaseFirestoreRule_ruleFile(context map[string]interface{}) string {
+	return Nprintf(`
+resource "sidkik_firebase_firestore_rule" "rule" {
+	rule_file = "%{rule_file}"
+}
+`, context)
+}
+
 func testAccCheckFirebaseFirestoreRuleDestroyProducer(t *testing.T) func(s *terraform.State) error {
 	return func(s *terraform.State) error {
 		// for name, rs := range s.RootModule().Resources {
@@ -79,3 +365,98 @@ func testAccCheckFirebaseFirestoreRuleDestroyProducer(t *testing.T) func(s *terr
 		return nil
 	}
 }
+
+func Test_flattenFirebaseRuleNameForRelease(t *testing.T) {
+	response := `{
+		"releases": [
+			{
+				"name": "projects/acct-1tcgeonjjgqi/releases/cloud.firestore",
+				"rulesetName": "projects/acct-1tcgeonjjgqi/rulesets/016ba66a-680d-4b81-9217-5c754f558abd",
+				"createTime": "2021-12-15T18:57:44.361618Z",
+				"updateTime": "2021-12-16T18:05:35.515330Z"
+			},
+			{
+				"name": "projects/acct-1tcgeonjjgqi/releases/cloud.firestore/orders",
+				"rulesetName": "projects/acct-1tcgeonjjgqi/rulesets/8d5a7c1e-2b9f-4c61-a0c7-0f1b3e7d2a44",
+				"createTime": "2021-12-17T10:12:01.000000Z",
+				"updateTime": "2021-12-17T10:12:01.000000Z"
+			}
+		]
+	}`
+
+	tests := []struct {
+		name     string
+		database string
+		want     interface{}
+	}{
+		{
+			name:     "default database",
+			database: "(default)",
+			want:     "projects/acct-1tcgeonjjgqi/rulesets/016ba66a-680d-4b81-9217-5c754f558abd",
+		},
+		{
+			name:     "named database",
+			database: "orders",
+			want:     "projects/acct-1tcgeonjjgqi/rulesets/8d5a7c1e-2b9f-4c61-a0c7-0f1b3e7d2a44",
+		},
+		{
+			name:     "missing database",
+			database: "order",
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result map[string]interface{}
+			json.Unmarshal([]byte(response), &result)
+
+			d := &ResourceDataMock{
+				FieldsInSchema: map[string]interface{}{
+					"project":  "acct-1tcgeonjjgqi",
+					"database": tt.database,
+				},
+			}
+			releaseName, err := replaceVars(d, &Config{}, firebaseFirestoreReleaseTemplate(d))
+			if err != nil {
+				t.Fatalf("replaceVars() error = %v", err)
+			}
+			if got := flattenFirebaseRuleNameForRelease(result["releases"].([]interface{}), releaseName, nil, nil); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("flattenFirebaseRuleNameForRelease() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirebaseFirestoreRule_ruleChangeUpdatesInPlace(t *testing.T) {
+	t.Parallel()
+
+	r := resourceFirebaseFirestoreRule()
+	oldRule := "service cloud.firestore {\n  match /databases/{database}/documents {\n    match /{document=**} {\n      allow read: if false;\n    }\n  }\n}\n"
+	newRule := "service cloud.firestore {\n  match /databases/{database}/documents {\n    match /{document=**} {\n      allow read: if request.auth != null;\n    }\n  }\n}\n"
+
+	state := &terraform.InstanceState{
+		ID: "projects/my-project/rulesets/abc",
+		Attributes: map[string]string{
+			"id":            "projects/my-project/rulesets/abc",
+			"name":          "projects/my-project/rulesets/abc",
+			"rule":          oldRule,
+			"database":      "(default)",
+			"on_destroy":    "keep",
+			"source_sha256": firebaseRulesSourceSha256(expandFirebaseRulesFiles(oldRule, nil, "firestore.rules")),
+		},
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"rule": newRule,
+	})
+
+	diff, err := r.Diff(context.Background(), state, config, &Config{})
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if diff == nil || diff.Attributes["name"] == nil || !diff.Attributes["name"].NewComputed {
+		t.Fatalf("Diff() expected name to be recomputed, got %#v", diff)
+	}
+	if diff.RequiresNew() {
+		t.Errorf("Diff() requires replacement, want an in-place update: %#v", diff.Attributes)
+	}
+}
@@ -0,0 +1,175 @@
+package sidkik
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceFirebaseRelease() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceFirebaseReleaseCreate,
+		Read:   resourceFirebaseReleaseRead,
+		Update: resourceFirebaseReleaseUpdate,
+		Delete: resourceFirebaseReleaseDelete,
+
+		Importer: &schema.ResourceImporter{
+			State: resourceFirebaseReleaseImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(4 * time.Minute),
+			Update: schema.DefaultTimeout(4 * time.Minute),
+			Delete: schema.DefaultTimeout(4 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringDoesNotMatch(regexp.MustCompile("^projects/"), "must be the release name within the project, e.g. cloud.firestore"),
+				Description:  `Name of the release within the project, e.g. cloud.firestore, cloud.firestore/{{database}} or firebase.storage/{{bucket}}`,
+			},
+			"ruleset_name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringMatch(regexp.MustCompile("^projects/[^/]+/rulesets/[^/]+$"), "must be of the form projects/{{project}}/rulesets/{{ruleset_id}}"),
+				Description:  `Name of the ruleset the release points at, e.g. projects/{{project}}/rulesets/{{ruleset_id}}`,
+			},
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"create_time": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `Time the release was created`,
+			},
+			"update_time": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `Time the release was last updated`,
+			},
+		},
+		UseJSONNumber: true,
+	}
+}
+
+const firebaseReleaseTemplate = "projects/{{project}}/releases/{{name}}"
+
+func resourceFirebaseReleaseCreate(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+
+	if err := releaseFirebaseRuleset(d, meta, firebaseReleaseTemplate, d.Get("ruleset_name").(string), d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
+
+	id, err := replaceVars(d, config, firebaseReleaseTemplate)
+	if err != nil {
+		return fmt.Errorf("Error constructing id: %s", err)
+	}
+	d.SetId(id)
+
+	return resourceFirebaseReleaseRead(d, meta)
+}
+
+func resourceFirebaseReleaseRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	}
+
+	url, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}"+d.Id())
+	if err != nil {
+		return err
+	}
+
+	res, err := sendRequest(config, "GET", project, url, userAgent, nil)
+	if err != nil {
+		return handleNotFoundError(err, d, fmt.Sprintf("FirebaseRelease %q", d.Id()))
+	}
+
+	if err := d.Set("project", project); err != nil {
+		return fmt.Errorf("Error reading FirebaseRelease: %s", err)
+	}
+	if err := d.Set("ruleset_name", res["rulesetName"]); err != nil {
+		return fmt.Errorf("Error reading FirebaseRelease: %s", err)
+	}
+	if err := d.Set("create_time", res["createTime"]); err != nil {
+		return fmt.Errorf("Error reading FirebaseRelease: %s", err)
+	}
+	if err := d.Set("update_time", res["updateTime"]); err != nil {
+		return fmt.Errorf("Error reading FirebaseRelease: %s", err)
+	}
+
+	return nil
+}
+
+func resourceFirebaseReleaseUpdate(d *schema.ResourceData, meta interface{}) error {
+	if d.HasChange("ruleset_name") {
+		if err := releaseFirebaseRuleset(d, meta, firebaseReleaseTemplate, d.Get("ruleset_name").(string), d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return err
+		}
+	}
+
+	return resourceFirebaseReleaseRead(d, meta)
+}
+
+func resourceFirebaseReleaseDelete(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	}
+
+	url, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}"+d.Id())
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Deleting FirebaseRelease %q", d.Id())
+
+	res, err := sendRequestWithTimeout(config, "DELETE", project, url, userAgent, nil, d.Timeout(schema.TimeoutDelete))
+	if err != nil {
+		return handleNotFoundError(err, d, "FirebaseRelease")
+	}
+
+	log.Printf("[DEBUG] Finished deleting FirebaseRelease %q: %#v", d.Id(), res)
+	return nil
+}
+
+func resourceFirebaseReleaseImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	config := meta.(*Config)
+	if err := parseImportId([]string{
+		"^projects/(?P<project>[^/]+)/releases/(?P<name>.+)$",
+	}, d, config); err != nil {
+		return nil, err
+	}
+
+	// Replace import id for the resource id
+	id, err := replaceVars(d, config, firebaseReleaseTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("Error constructing id: %s", err)
+	}
+	d.SetId(id)
+
+	return []*schema.ResourceData{d}, nil
+}
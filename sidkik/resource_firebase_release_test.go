@@ -0,0 +1,92 @@
+package sidkik
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccFirebaseRelease_update(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{
+		"random_suffix": randString(t, 10),
+		"released":      "first",
+	}
+	contextUpdated := map[string]interface{}{
+		"random_suffix": context["random_suffix"],
+		"released":      "second",
+	}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseRelease_release(context),
+				Check: resource.TestCheckResourceAttrPair(
+					"sidkik_firebase_release.release", "ruleset_name",
+					"sidkik_firebase_ruleset.first", "name",
+				),
+			},
+			{
+				ResourceName:      "sidkik_firebase_release.release",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: testAccFirebaseRelease_release(contextUpdated),
+				Check: resource.TestCheckResourceAttrPair(
+					"sidkik_firebase_release.release", "ruleset_name",
+					"sidkik_firebase_ruleset.second", "name",
+				),
+			},
+			{
+				ResourceName:      "sidkik_firebase_release.release",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccFirebaseRelease_release(context map[string]interface{}) string {
+	return Nprintf(`
+resource "sidkik_firebase_ruleset" "first" {
+	files {
+		name    = "firestore.rules"
+		content = <<EOT
+rules_version = '2';
+service cloud.firestore {
+	match /databases/{database}/documents {
+		match /{document=**} {
+			allow read, write: if false;
+		}
+	}
+}
+EOT
+	}
+}
+
+resource "sidkik_firebase_ruleset" "second" {
+	files {
+		name    = "firestore.rules"
+		content = <<EOT
+rules_version = '2';
+service cloud.firestore {
+	match /databases/{database}/documents {
+		match /{document=**} {
+			allow read: if request.auth != null;
+		}
+	}
+}
+EOT
+	}
+}
+
+resource "sidkik_firebase_release" "release" {
+	name         = "tf-test-release-%{random_suffix}"
+	ruleset_name = sidkik_firebase_ruleset.%{released}.name
+}
+`, context)
+}
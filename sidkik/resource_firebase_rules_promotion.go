@@ -0,0 +1,276 @@
+package sidkik
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceFirebaseRulesPromotion() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceFirebaseRulesPromotionCreate,
+		Read:   resourceFirebaseRulesPromotionRead,
+		Delete: resourceFirebaseRulesPromotionDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(4 * time.Minute),
+			Delete: schema.DefaultTimeout(4 * time.Minute),
+		},
+
+		CustomizeDiff: firebaseRulesPromotionCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"source_project": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: `Project the rules are promoted from`,
+			},
+			"source_release": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringDoesNotMatch(regexp.MustCompile("^projects/"), "must be the release name within the project, e.g. cloud.firestore"),
+				Description:  `Name of the release in source_project whose ruleset is promoted, e.g. cloud.firestore or firebase.storage/{{bucket}}`,
+			},
+			"release": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringDoesNotMatch(regexp.MustCompile("^projects/"), "must be the release name within the project, e.g. cloud.firestore"),
+				Description:  `Name of the release in project the ruleset is promoted to. Defaults to source_release, set it when the names differ, e.g. for firebase.storage/{{bucket}}`,
+			},
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"source_ruleset_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `The ruleset currently released under source_release in source_project, updated on every refresh`,
+			},
+			"source_ruleset_sha256": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `SHA-256 of the source of source_ruleset_name, ignoring whitespace and comments. The resource is replaced when it differs from ruleset_sha256`,
+			},
+			"promoted_source_ruleset_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `The ruleset in source_project that was promoted. Unlike source_ruleset_name it is only set when the resource is created`,
+			},
+			"promoted_source_ruleset_sha256": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `SHA-256 of the source of promoted_source_ruleset_name, ignoring whitespace and comments`,
+			},
+			"ruleset_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `The ruleset released under release in project, holding a copy of the source ruleset`,
+			},
+			"ruleset_sha256": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `SHA-256 of the source of ruleset_name, ignoring whitespace and comments. Differs from source_ruleset_sha256 when the source release changed since the last promotion`,
+			},
+			"update_time": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `Time the release in project was last updated`,
+			},
+		},
+		UseJSONNumber: true,
+	}
+}
+
+const (
+	firebaseRulesPromotionTemplate       = "projects/{{project}}/releases/{{release}}"
+	firebaseRulesPromotionSourceTemplate = "projects/{{source_project}}/releases/{{source_release}}"
+)
+
+func resourceFirebaseRulesPromotionCreate(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+
+	if d.Get("release").(string) == "" {
+		if err := d.Set("release", d.Get("source_release")); err != nil {
+			return fmt.Errorf("Error setting release: %s", err)
+		}
+	}
+
+	if err := promoteFirebaseRules(d, meta, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
+
+	id, err := replaceVars(d, config, firebaseRulesPromotionTemplate)
+	if err != nil {
+		return fmt.Errorf("Error constructing id: %s", err)
+	}
+	d.SetId(id)
+
+	return resourceFirebaseRulesPromotionRead(d, meta)
+}
+
+func resourceFirebaseRulesPromotionRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	}
+
+	release, source, err := readFirebaseReleasedRuleset(d, config, userAgent, project, d.Id())
+	if err != nil {
+		return handleNotFoundError(err, d, fmt.Sprintf("FirebaseRulesPromotion %q", d.Id()))
+	}
+
+	if err := d.Set("project", project); err != nil {
+		return fmt.Errorf("Error reading FirebaseRulesPromotion: %s", err)
+	}
+	if err := d.Set("ruleset_name", release["rulesetName"]); err != nil {
+		return fmt.Errorf("Error reading FirebaseRulesPromotion: %s", err)
+	}
+	if err := d.Set("update_time", release["updateTime"]); err != nil {
+		return fmt.Errorf("Error reading FirebaseRulesPromotion: %s", err)
+	}
+	if err := d.Set("ruleset_sha256", firebaseRulesSourceSha256(flattenFirebaseRulesFiles(source, d, config))); err != nil {
+		return fmt.Errorf("Error reading FirebaseRulesPromotion: %s", err)
+	}
+
+	sourceReleaseName, err := replaceVars(d, config, firebaseRulesPromotionSourceTemplate)
+	if err != nil {
+		return err
+	}
+	sourceRelease, sourceSource, err := readFirebaseReleasedRuleset(d, config, userAgent, d.Get("source_project").(string), sourceReleaseName)
+	if err != nil {
+		if isGoogleApiErrorWithCode(err, 404) {
+			log.Printf("[WARN] Source release %q of FirebaseRulesPromotion %q no longer exists, keeping the last promoted ruleset", sourceReleaseName, d.Id())
+			return nil
+		}
+		return fmt.Errorf("Error reading FirebaseRulesPromotion: %s", err)
+	}
+
+	if err := d.Set("source_ruleset_name", sourceRelease["rulesetName"]); err != nil {
+		return fmt.Errorf("Error reading FirebaseRulesPromotion: %s", err)
+	}
+	if err := d.Set("source_ruleset_sha256", firebaseRulesSourceSha256(flattenFirebaseRulesFiles(sourceSource, d, config))); err != nil {
+		return fmt.Errorf("Error reading FirebaseRulesPromotion: %s", err)
+	}
+
+	return nil
+}
+
+func resourceFirebaseRulesPromotionDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[INFO] Not deleting release %q of FirebaseRulesPromotion - the promoted ruleset stays released", d.Id())
+	return nil
+}
+
+// firebaseRulesPromotionCustomizeDiff replaces the promotion when the source of
+// the released ruleset in project differs from the one in source_project,
+// either because the source release moved on or the target was changed.
+func firebaseRulesPromotionCustomizeDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	source := d.Get("source_ruleset_sha256").(string)
+	if source == "" || source == d.Get("ruleset_sha256").(string) {
+		return nil
+	}
+
+	for _, k := range []string{"ruleset_name", "ruleset_sha256", "update_time"} {
+		if err := d.SetNewComputed(k); err != nil {
+			return err
+		}
+	}
+	return d.ForceNew("ruleset_name")
+}
+
+// promoteFirebaseRules copies the source of the ruleset released in
+// source_project into a new ruleset in project and releases it.
+func promoteFirebaseRules(d *schema.ResourceData, meta interface{}, timeout time.Duration) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	sourceReleaseName, err := replaceVars(d, config, firebaseRulesPromotionSourceTemplate)
+	if err != nil {
+		return err
+	}
+
+	sourceRelease, source, err := readFirebaseReleasedRuleset(d, config, userAgent, d.Get("source_project").(string), sourceReleaseName)
+	if err != nil {
+		return fmt.Errorf("Error reading source release %q: %s", sourceReleaseName, err)
+	}
+	sourceRulesetName := sourceRelease["rulesetName"]
+
+	log.Printf("[DEBUG] Promoting Firebase Ruleset %q", sourceRulesetName)
+
+	files := flattenFirebaseRulesFiles(source, d, config)
+	obj := make(map[string]interface{})
+	obj["source"] = map[string]interface{}{
+		"files": files,
+	}
+	if err := markFirebaseRulesetRelease(d, config, obj, firebaseRulesPromotionTemplate); err != nil {
+		return err
+	}
+	rulesetName, err := insertFirebaseRuleset(d, meta, obj, timeout)
+	if err != nil {
+		return err
+	}
+
+	if err := releaseFirebaseRuleset(d, meta, firebaseRulesPromotionTemplate, rulesetName, timeout); err != nil {
+		return err
+	}
+
+	// Read refreshes source_ruleset_name, the promoted ruleset is only recorded here
+	if err := d.Set("promoted_source_ruleset_name", sourceRulesetName); err != nil {
+		return fmt.Errorf("Error setting promoted_source_ruleset_name: %s", err)
+	}
+	if err := d.Set("promoted_source_ruleset_sha256", firebaseRulesSourceSha256(files)); err != nil {
+		return fmt.Errorf("Error setting promoted_source_ruleset_sha256: %s", err)
+	}
+
+	log.Printf("[DEBUG] Finished promoting Firebase Ruleset %q as %q", sourceRulesetName, rulesetName)
+	return nil
+}
+
+// readFirebaseReleasedRuleset returns the release named releaseName, e.g.
+// projects/{{project}}/releases/cloud.firestore, and the source of the ruleset
+// it points at.
+func readFirebaseReleasedRuleset(d TerraformResourceData, config *Config, userAgent, project, releaseName string) (map[string]interface{}, interface{}, error) {
+	url, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}"+releaseName)
+	if err != nil {
+		return nil, nil, err
+	}
+	release, err := sendRequest(config, "GET", project, url, userAgent, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rulesetName, _ := release["rulesetName"].(string)
+	url, err = replaceVars(d, config, "{{FirebaseRulesBasePath}}"+rulesetName)
+	if err != nil {
+		return nil, nil, err
+	}
+	ruleset, err := sendRequest(config, "GET", project, url, userAgent, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return release, ruleset["source"], nil
+}
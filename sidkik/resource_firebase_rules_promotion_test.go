@@ -0,0 +1,128 @@
+package sidkik
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccFirebaseRulesPromotion_update(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{
+		"random_suffix": randString(t, 10),
+		"released":      "first",
+	}
+	contextUpdated := map[string]interface{}{
+		"random_suffix": context["random_suffix"],
+		"released":      "second",
+	}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseRulesPromotion_promotion(context),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"sidkik_firebase_rules_promotion.promotion", "source_ruleset_name",
+						"sidkik_firebase_ruleset.first", "name",
+					),
+					resource.TestCheckResourceAttrPair(
+						"sidkik_firebase_rules_promotion.promotion", "promoted_source_ruleset_name",
+						"sidkik_firebase_ruleset.first", "name",
+					),
+					resource.TestCheckResourceAttrPair(
+						"sidkik_firebase_rules_promotion.promotion", "promoted_source_ruleset_sha256",
+						"sidkik_firebase_rules_promotion.promotion", "ruleset_sha256",
+					),
+					resource.TestCheckResourceAttrPair(
+						"sidkik_firebase_rules_promotion.promotion", "ruleset_sha256",
+						"sidkik_firebase_rules_promotion.promotion", "source_ruleset_sha256",
+					),
+				),
+			},
+			{
+				// the promotion only notices the new source release on the next refresh
+				Config:             testAccFirebaseRulesPromotion_promotion(contextUpdated),
+				ExpectNonEmptyPlan: true,
+				Check: resource.ComposeTestCheckFunc(
+					// refreshes do not overwrite the ruleset that was promoted
+					resource.TestCheckResourceAttrPair(
+						"sidkik_firebase_rules_promotion.promotion", "promoted_source_ruleset_name",
+						"sidkik_firebase_ruleset.first", "name",
+					),
+				),
+			},
+			{
+				Config: testAccFirebaseRulesPromotion_promotion(contextUpdated),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"sidkik_firebase_rules_promotion.promotion", "source_ruleset_name",
+						"sidkik_firebase_ruleset.second", "name",
+					),
+					resource.TestCheckResourceAttrPair(
+						"sidkik_firebase_rules_promotion.promotion", "promoted_source_ruleset_name",
+						"sidkik_firebase_ruleset.second", "name",
+					),
+					resource.TestCheckResourceAttrPair(
+						"sidkik_firebase_rules_promotion.promotion", "promoted_source_ruleset_sha256",
+						"sidkik_firebase_rules_promotion.promotion", "ruleset_sha256",
+					),
+					resource.TestCheckResourceAttrPair(
+						"sidkik_firebase_rules_promotion.promotion", "ruleset_sha256",
+						"sidkik_firebase_rules_promotion.promotion", "source_ruleset_sha256",
+					),
+				),
+			},
+		},
+	})
+}
+
+func testAccFirebaseRulesPromotion_promotion(context map[string]interface{}) string {
+	return Nprintf(`
+resource "sidkik_firebase_ruleset" "first" {
+	files {
+		name    = "firestore.rules"
+		content = <<EOT
+rules_version = '2';
+service cloud.firestore {
+	match /databases/{database}/documents {
+		match /{document=**} {
+			allow read, write: if false;
+		}
+	}
+}
+EOT
+	}
+}
+
+resource "sidkik_firebase_ruleset" "second" {
+	files {
+		name    = "firestore.rules"
+		content = <<EOT
+rules_version = '2';
+service cloud.firestore {
+	match /databases/{database}/documents {
+		match /users/{userId} {
+			allow read: if request.auth != null;
+		}
+	}
+}
+EOT
+	}
+}
+
+resource "sidkik_firebase_release" "staging" {
+	name         = "tf-test-staging-%{random_suffix}"
+	ruleset_name = sidkik_firebase_ruleset.%{released}.name
+}
+
+resource "sidkik_firebase_rules_promotion" "promotion" {
+	source_project = sidkik_firebase_release.staging.project
+	source_release = sidkik_firebase_release.staging.name
+	release        = "tf-test-promoted-%{random_suffix}"
+}
+`, context)
+}
@@ -0,0 +1,311 @@
+package sidkik
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceFirebaseRulesRollback() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceFirebaseRulesRollbackCreate,
+		Read:   resourceFirebaseRulesRollbackRead,
+		Update: resourceFirebaseRulesRollbackUpdate,
+		Delete: resourceFirebaseRulesRollbackDelete,
+
+		Importer: &schema.ResourceImporter{
+			State: resourceFirebaseRulesRollbackImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(4 * time.Minute),
+			Update: schema.DefaultTimeout(4 * time.Minute),
+			Delete: schema.DefaultTimeout(4 * time.Minute),
+		},
+
+		CustomizeDiff: firebaseRulesRollbackCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"release": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringMatch(regexp.MustCompile("^(cloud\\.firestore|firebase\\.storage)(/.+)?$"), "must be cloud.firestore, cloud.firestore/{{database}} or firebase.storage/{{bucket}}"),
+				Description:  `Name of the release to roll back, e.g. cloud.firestore, cloud.firestore/{{database}} or firebase.storage/{{bucket}}`,
+			},
+			"ruleset_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"ruleset_name", "as_of"},
+				ValidateFunc: validation.StringMatch(regexp.MustCompile("^projects/[^/]+/rulesets/[^/]+$"), "must be of the form projects/{{project}}/rulesets/{{ruleset_id}}"),
+				Description:  `Name of the earlier ruleset to release, e.g. projects/{{project}}/rulesets/{{ruleset_id}}. Resolved from as_of when not set`,
+			},
+			"as_of": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"ruleset_name", "as_of"},
+				ValidateFunc: validateRFC3339Date,
+				Description:  `Release the latest ruleset for this release created before this RFC3339 timestamp. Only rulesets this provider created for the release, or the one currently released under it, are considered when the service has other releases`,
+			},
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"on_destroy": firebaseRulesOnDestroySchema(),
+			"previous_ruleset_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `The ruleset that was released before the rollback`,
+			},
+			"update_time": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `Time the release was last updated`,
+			},
+		},
+		UseJSONNumber: true,
+	}
+}
+
+const firebaseRulesRollbackTemplate = "projects/{{project}}/releases/{{release}}"
+
+func resourceFirebaseRulesRollbackCreate(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+
+	previous, err := getFirebaseReleaseRulesetName(d, meta, firebaseRulesRollbackTemplate)
+	if err != nil {
+		return err
+	}
+	if err := d.Set("previous_ruleset_name", previous); err != nil {
+		return fmt.Errorf("Error setting previous_ruleset_name: %s", err)
+	}
+
+	if err := rollbackFirebaseRelease(d, meta, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
+
+	id, err := replaceVars(d, config, firebaseRulesRollbackTemplate)
+	if err != nil {
+		return fmt.Errorf("Error constructing id: %s", err)
+	}
+	d.SetId(id)
+
+	return resourceFirebaseRulesRollbackRead(d, meta)
+}
+
+func resourceFirebaseRulesRollbackRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	}
+
+	url, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}"+d.Id())
+	if err != nil {
+		return err
+	}
+
+	res, err := sendRequest(config, "GET", project, url, userAgent, nil)
+	if err != nil {
+		return handleNotFoundError(err, d, fmt.Sprintf("FirebaseRulesRollback %q", d.Id()))
+	}
+
+	if err := d.Set("project", project); err != nil {
+		return fmt.Errorf("Error reading FirebaseRulesRollback: %s", err)
+	}
+	if err := d.Set("ruleset_name", res["rulesetName"]); err != nil {
+		return fmt.Errorf("Error reading FirebaseRulesRollback: %s", err)
+	}
+	if err := d.Set("update_time", res["updateTime"]); err != nil {
+		return fmt.Errorf("Error reading FirebaseRulesRollback: %s", err)
+	}
+
+	return nil
+}
+
+func resourceFirebaseRulesRollbackUpdate(d *schema.ResourceData, meta interface{}) error {
+	if d.HasChange("ruleset_name") || d.HasChange("as_of") {
+		if err := rollbackFirebaseRelease(d, meta, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return err
+		}
+	}
+
+	return resourceFirebaseRulesRollbackRead(d, meta)
+}
+
+func resourceFirebaseRulesRollbackDelete(d *schema.ResourceData, meta interface{}) error {
+	if strings.HasPrefix(d.Get("release").(string), "firebase.storage") {
+		return destroyFirebaseRulesRelease(d, meta, firebaseRulesRollbackTemplate, "storage.rules", firebaseStorageLockDownRule)
+	}
+	return destroyFirebaseRulesRelease(d, meta, firebaseRulesRollbackTemplate, "firestore.rules", firebaseFirestoreLockDownRule)
+}
+
+func resourceFirebaseRulesRollbackImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	config := meta.(*Config)
+	if err := parseImportId([]string{
+		"^projects/(?P<project>[^/]+)/releases/(?P<release>.+)$",
+	}, d, config); err != nil {
+		return nil, err
+	}
+
+	// Replace import id for the resource id
+	id, err := replaceVars(d, config, firebaseRulesRollbackTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("Error constructing id: %s", err)
+	}
+	d.SetId(id)
+
+	// Explicitly set virtual fields to default values on import
+	if err := d.Set("on_destroy", "keep"); err != nil {
+		return nil, fmt.Errorf("Error setting on_destroy: %s", err)
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// firebaseRulesRollbackCustomizeDiff marks the ruleset as unknown when as_of
+// changes, since it is only resolved during apply.
+func firebaseRulesRollbackCustomizeDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Get("as_of").(string) == "" || !d.HasChange("as_of") {
+		return nil
+	}
+	return d.SetNewComputed("ruleset_name")
+}
+
+// rollbackFirebaseRelease points the release at ruleset_name, or at the ruleset
+// resolved from as_of.
+func rollbackFirebaseRelease(d *schema.ResourceData, meta interface{}, timeout time.Duration) error {
+	rulesetName := d.Get("ruleset_name").(string)
+	if asOf := d.Get("as_of").(string); asOf != "" {
+		config := meta.(*Config)
+		userAgent, err := generateUserAgentString(d, config.userAgent)
+		if err != nil {
+			return err
+		}
+
+		project, err := getProject(d, config)
+		if err != nil {
+			return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+		}
+
+		releaseName, err := replaceVars(d, config, firebaseRulesRollbackTemplate)
+		if err != nil {
+			return err
+		}
+
+		releases, err := listFirebaseRules(d, config, userAgent, "releases")
+		if err != nil {
+			return fmt.Errorf("Error listing Firebase Releases: %s", err)
+		}
+
+		rulesets, err := listFirebaseRules(d, config, userAgent, "rulesets")
+		if err != nil {
+			return fmt.Errorf("Error listing Firebase Rulesets: %s", err)
+		}
+
+		// the list omits the source, so it is read one ruleset at a time
+		sourceOf := func(name string) (interface{}, error) {
+			res, err := sendRequestWithTimeout(config, "GET", project, config.FirebaseRulesBasePath+name, userAgent, nil, timeout)
+			if err != nil {
+				return nil, fmt.Errorf("Error reading Firebase Ruleset %q: %s", name, err)
+			}
+			return res["source"], nil
+		}
+
+		rulesetName, err = firebaseRulesetAsOf(releases, rulesets, releaseName, asOf, sourceOf)
+		if err != nil {
+			return err
+		}
+		if rulesetName == "" {
+			return fmt.Errorf("Error rolling back Firebase Release: no ruleset for %s was created before %s", d.Get("release"), asOf)
+		}
+	}
+
+	log.Printf("[INFO] Rolling back Firebase release %q to ruleset %q", d.Get("release"), rulesetName)
+
+	if err := releaseFirebaseRuleset(d, meta, firebaseRulesRollbackTemplate, rulesetName, timeout); err != nil {
+		return err
+	}
+
+	if err := d.Set("ruleset_name", rulesetName); err != nil {
+		return fmt.Errorf("Error setting ruleset_name: %s", err)
+	}
+	return nil
+}
+
+// firebaseRulesReleaseService returns the service a release applies to, e.g.
+// cloud.firestore for cloud.firestore/my-database.
+func firebaseRulesReleaseService(release string) string {
+	return strings.SplitN(release, "/", 2)[0]
+}
+
+// firebaseRulesetAsOf returns the name of the latest ruleset created before
+// asOf for releaseName, or an empty string if there is none. Besides the
+// ruleset currently released under it, a ruleset belongs to the release when
+// its release marker names the release. Rulesets without a marker, e.g. those
+// created outside of Terraform, only belong to it when no other release of the
+// same service exists. Rulesets currently released under another release are
+// skipped.
+func firebaseRulesetAsOf(releases, rulesets []interface{}, releaseName, asOf string, sourceOf func(string) (interface{}, error)) (string, error) {
+	releaseId := firebaseRulesReleaseId(releaseName)
+	service := firebaseRulesReleaseService(releaseId)
+
+	current := ""
+	others := map[string]bool{}
+	for _, raw := range releases {
+		release := raw.(map[string]interface{})
+		name, _ := release["name"].(string)
+		rulesetName, _ := release["rulesetName"].(string)
+		if name == releaseName {
+			current = rulesetName
+		} else if firebaseRulesReleaseService(firebaseRulesReleaseId(name)) == service {
+			others[rulesetName] = true
+		}
+	}
+	if current == "" {
+		return "", fmt.Errorf("Error rolling back Firebase Release: release %q not found", releaseName)
+	}
+
+	for _, raw := range filterFirebaseRulesByCreateTime(rulesets, "", asOf) {
+		ruleset := raw.(map[string]interface{})
+		name, _ := ruleset["name"].(string)
+		if !firebaseRulesetHasService(ruleset, service) || (others[name] && name != current) {
+			continue
+		}
+		if name == current {
+			return name, nil
+		}
+
+		source, err := sourceOf(name)
+		if err != nil {
+			return "", err
+		}
+		release := firebaseRulesetRelease(source)
+		if release == releaseId || (release == "" && len(others) == 0) {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// firebaseRulesReleaseId returns the release id of a release name, e.g.
+// cloud.firestore/my-database for
+// projects/my-project/releases/cloud.firestore/my-database.
+func firebaseRulesReleaseId(releaseName string) string {
+	parts := strings.SplitN(releaseName, "/releases/", 2)
+	return parts[len(parts)-1]
+}
@@ -0,0 +1,192 @@
+package sidkik
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccFirebaseRulesRollback_rulesetName(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{
+		"random_suffix": randString(t, 10),
+	}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseRulesRollback_rulesetName(context),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"sidkik_firebase_rules_rollback.rollback", "ruleset_name",
+						"sidkik_firebase_ruleset.first", "name",
+					),
+					resource.TestCheckResourceAttrPair(
+						"sidkik_firebase_rules_rollback.rollback", "previous_ruleset_name",
+						"sidkik_firebase_ruleset.second", "name",
+					),
+				),
+			},
+			{
+				ResourceName:            "sidkik_firebase_rules_rollback.rollback",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"on_destroy", "previous_ruleset_name"},
+			},
+		},
+	})
+}
+
+func testAccFirebaseRulesRollback_rulesetName(context map[string]interface{}) string {
+	return Nprintf(`
+resource "sidkik_firebase_ruleset" "first" {
+	files {
+		name    = "firestore.rules"
+		content = <<EOT
+rules_version = '2';
+service cloud.firestore {
+	match /databases/{database}/documents {
+		match /{document=**} {
+			allow read, write: if false;
+		}
+	}
+}
+EOT
+	}
+}
+
+resource "sidkik_firebase_ruleset" "second" {
+	files {
+		name    = "firestore.rules"
+		content = <<EOT
+rules_version = '2';
+service cloud.firestore {
+	match /databases/{database}/documents {
+		match /{document=**} {
+			allow read: if request.auth != null;
+		}
+	}
+}
+EOT
+	}
+}
+
+resource "sidkik_firebase_release" "release" {
+	name         = "cloud.firestore/tf-test-%{random_suffix}"
+	ruleset_name = sidkik_firebase_ruleset.second.name
+
+	lifecycle {
+		ignore_changes = [ruleset_name]
+	}
+}
+
+resource "sidkik_firebase_rules_rollback" "rollback" {
+	release      = sidkik_firebase_release.release.name
+	ruleset_name = sidkik_firebase_ruleset.first.name
+	on_destroy   = "restore_previous"
+}
+`, context)
+}
+
+func Test_firebaseRulesetAsOf(t *testing.T) {
+	// the (default) and orders databases were released in turns, legacy-1
+	// predates the release marker
+	var releases, rulesets []interface{}
+	if err := json.Unmarshal([]byte(`[
+		{"name": "projects/my-project/releases/cloud.firestore", "rulesetName": "projects/my-project/rulesets/default-3"},
+		{"name": "projects/my-project/releases/cloud.firestore/orders", "rulesetName": "projects/my-project/rulesets/orders-2"},
+		{"name": "projects/my-project/releases/firebase.storage/my-project.appspot.com", "rulesetName": "projects/my-project/rulesets/storage-2"}
+	]`), &releases); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`[
+		{"name": "projects/my-project/rulesets/legacy-1", "createTime": "2021-12-09T00:00:00Z", "metadata": {"services": ["cloud.firestore"]}},
+		{"name": "projects/my-project/rulesets/storage-1", "createTime": "2021-12-09T12:00:00Z", "metadata": {"services": ["firebase.storage"]}},
+		{"name": "projects/my-project/rulesets/default-1", "createTime": "2021-12-10T00:00:00Z", "metadata": {"services": ["cloud.firestore"]}},
+		{"name": "projects/my-project/rulesets/orders-1", "createTime": "2021-12-11T00:00:00Z", "metadata": {"services": ["cloud.firestore"]}},
+		{"name": "projects/my-project/rulesets/storage-2", "createTime": "2021-12-11T12:00:00Z", "metadata": {"services": ["firebase.storage"]}},
+		{"name": "projects/my-project/rulesets/default-2", "createTime": "2021-12-12T00:00:00Z", "metadata": {"services": ["cloud.firestore"]}},
+		{"name": "projects/my-project/rulesets/orders-2", "createTime": "2021-12-13T00:00:00Z", "metadata": {"services": ["cloud.firestore"]}},
+		{"name": "projects/my-project/rulesets/default-3", "createTime": "2021-12-14T00:00:00Z", "metadata": {"services": ["cloud.firestore"]}}
+	]`), &rulesets); err != nil {
+		t.Fatal(err)
+	}
+
+	// every ruleset uses the same file name, only the release marker differs
+	source := func(release string) interface{} {
+		content := "service cloud.firestore {}\n"
+		if release != "" {
+			content += "\n" + firebaseRulesReleaseMarker + release + "\n"
+		}
+		return map[string]interface{}{"files": []interface{}{
+			map[string]interface{}{"name": "firestore.rules", "content": content},
+		}}
+	}
+	sources := map[string]interface{}{
+		"projects/my-project/rulesets/legacy-1":  source(""),
+		"projects/my-project/rulesets/default-1": source("cloud.firestore"),
+		"projects/my-project/rulesets/default-2": source("cloud.firestore"),
+		"projects/my-project/rulesets/default-3": source("cloud.firestore"),
+		"projects/my-project/rulesets/orders-1":  source("cloud.firestore/orders"),
+		"projects/my-project/rulesets/orders-2":  source("cloud.firestore/orders"),
+		"projects/my-project/rulesets/storage-1": source(""),
+		"projects/my-project/rulesets/storage-2": source("firebase.storage/my-project.appspot.com"),
+	}
+	sourceOf := func(name string) (interface{}, error) {
+		return sources[name], nil
+	}
+
+	cases := map[string]struct {
+		release  string
+		asOf     string
+		expected string
+	}{
+		"latest before": {
+			release:  "projects/my-project/releases/cloud.firestore",
+			asOf:     "2021-12-15T00:00:00Z",
+			expected: "projects/my-project/rulesets/default-3",
+		},
+		"skips the other database": {
+			release:  "projects/my-project/releases/cloud.firestore",
+			asOf:     "2021-12-11T12:00:00Z",
+			expected: "projects/my-project/rulesets/default-1",
+		},
+		"before is exclusive": {
+			release:  "projects/my-project/releases/cloud.firestore",
+			asOf:     "2021-12-14T00:00:00Z",
+			expected: "projects/my-project/rulesets/default-2",
+		},
+		"named database": {
+			release:  "projects/my-project/releases/cloud.firestore/orders",
+			asOf:     "2021-12-12T12:00:00Z",
+			expected: "projects/my-project/rulesets/orders-1",
+		},
+		"unmarked with other releases": {
+			release:  "projects/my-project/releases/cloud.firestore/orders",
+			asOf:     "2021-12-11T00:00:00Z",
+			expected: "",
+		},
+		"unmarked as the only release": {
+			release:  "projects/my-project/releases/firebase.storage/my-project.appspot.com",
+			asOf:     "2021-12-11T00:00:00Z",
+			expected: "projects/my-project/rulesets/storage-1",
+		},
+	}
+
+	for tn, tc := range cases {
+		got, err := firebaseRulesetAsOf(releases, rulesets, tc.release, tc.asOf, sourceOf)
+		if err != nil {
+			t.Errorf("bad: %s, unexpected error %s", tn, err)
+		} else if got != tc.expected {
+			t.Errorf("bad: %s, expected %q, got %q", tn, tc.expected, got)
+		}
+	}
+
+	if _, err := firebaseRulesetAsOf(releases, rulesets, "projects/my-project/releases/cloud.firestore/missing", "2021-12-13T12:00:00Z", sourceOf); err == nil {
+		t.Errorf("bad: expected an error for a missing release")
+	}
+}
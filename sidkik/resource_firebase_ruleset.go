@@ -0,0 +1,213 @@
+package sidkik
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceFirebaseRuleset() *schema.Resource {
+	// rulesets are immutable so their test cases only run on create
+	testCase := firebaseRulesTestCaseSchema()
+	testCase.ForceNew = true
+
+	return &schema.Resource{
+		Create: resourceFirebaseRulesetCreate,
+		Read:   resourceFirebaseRulesetRead,
+		Delete: resourceFirebaseRulesetDelete,
+
+		Importer: &schema.ResourceImporter{
+			State: resourceFirebaseRulesetImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(4 * time.Minute),
+			Delete: schema.DefaultTimeout(4 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"files": {
+				Type:        schema.TypeList,
+				Required:    true,
+				ForceNew:    true,
+				MinItems:    1,
+				Description: `Source files of the ruleset`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: `Name of the source file`,
+						},
+						"content": {
+							Type:             schema.TypeString,
+							Required:         true,
+							ForceNew:         true,
+							Description:      `Content of the source file`,
+							DiffSuppressFunc: firebaseRulesSourceDiffSuppress,
+							ValidateFunc:     validateFirebaseRulesSource,
+						},
+					},
+				},
+			},
+			"test_case": testCase,
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `name of the ruleset, e.g. projects/{{project}}/rulesets/{{ruleset_id}}`,
+			},
+			"create_time": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `Time the ruleset was created`,
+			},
+			"services": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: `Services the ruleset has declarations for, e.g. cloud.firestore or firebase.storage`,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+		UseJSONNumber: true,
+	}
+}
+
+func resourceFirebaseRulesetCreate(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	}
+
+	obj := make(map[string]interface{})
+	obj["files"] = d.Get("files")
+
+	obj, err = resourceFirebaseRuleEncoder(d, meta, obj)
+	if err != nil {
+		return err
+	}
+
+	if err := runFirebaseRulesTestCases(d, config, project, userAgent, obj["source"].(map[string]interface{})); err != nil {
+		return err
+	}
+
+	rulesetName, err := insertFirebaseRuleset(d, meta, obj, d.Timeout(schema.TimeoutCreate))
+	if err != nil {
+		return err
+	}
+
+	d.SetId(rulesetName)
+
+	return resourceFirebaseRulesetRead(d, meta)
+}
+
+func resourceFirebaseRulesetRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	}
+
+	url, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}"+d.Id())
+	if err != nil {
+		return err
+	}
+
+	res, err := sendRequest(config, "GET", project, url, userAgent, nil)
+	if err != nil {
+		return handleNotFoundError(err, d, fmt.Sprintf("FirebaseRuleset %q", d.Id()))
+	}
+
+	if err := d.Set("project", project); err != nil {
+		return fmt.Errorf("Error reading FirebaseRuleset: %s", err)
+	}
+	if err := d.Set("name", res["name"]); err != nil {
+		return fmt.Errorf("Error reading FirebaseRuleset: %s", err)
+	}
+	if err := d.Set("files", flattenFirebaseRulesFiles(res["source"], d, config)); err != nil {
+		return fmt.Errorf("Error reading FirebaseRuleset: %s", err)
+	}
+	if err := d.Set("create_time", res["createTime"]); err != nil {
+		return fmt.Errorf("Error reading FirebaseRuleset: %s", err)
+	}
+	if err := d.Set("services", flattenFirebaseRulesetServices(res["metadata"], d, config)); err != nil {
+		return fmt.Errorf("Error reading FirebaseRuleset: %s", err)
+	}
+
+	return nil
+}
+
+func resourceFirebaseRulesetDelete(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	userAgent, err := generateUserAgentString(d, config.userAgent)
+	if err != nil {
+		return err
+	}
+
+	project, err := getProject(d, config)
+	if err != nil {
+		return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+	}
+
+	url, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}"+d.Id())
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Deleting FirebaseRuleset %q", d.Id())
+
+	res, err := sendRequestWithTimeout(config, "DELETE", project, url, userAgent, nil, d.Timeout(schema.TimeoutDelete))
+	if err != nil {
+		return handleNotFoundError(err, d, "FirebaseRuleset")
+	}
+
+	log.Printf("[DEBUG] Finished deleting FirebaseRuleset %q: %#v", d.Id(), res)
+	return nil
+}
+
+func resourceFirebaseRulesetImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	config := meta.(*Config)
+	if err := parseImportId([]string{
+		"^projects/(?P<project>[^/]+)/rulesets/(?P<name>[^/]+)$",
+	}, d, config); err != nil {
+		return nil, err
+	}
+
+	// Replace import id for the resource id
+	id, err := replaceVars(d, config, "projects/{{project}}/rulesets/{{name}}")
+	if err != nil {
+		return nil, fmt.Errorf("Error constructing id: %s", err)
+	}
+	d.SetId(id)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func flattenFirebaseRulesetServices(v interface{}, d *schema.ResourceData, config *Config) interface{} {
+	metadata, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return metadata["services"]
+}
@@ -0,0 +1,63 @@
+package sidkik
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccFirebaseRuleset_files(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseRuleset_files(context),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("sidkik_firebase_ruleset.ruleset", "create_time"),
+					resource.TestCheckResourceAttr("sidkik_firebase_ruleset.ruleset", "services.0", "cloud.firestore"),
+				),
+			},
+			{
+				ResourceName:            "sidkik_firebase_ruleset.ruleset",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"test_case"},
+			},
+		},
+	})
+}
+
+func testAccFirebaseRuleset_files(context map[string]interface{}) string {
+	return Nprintf(`
+resource "sidkik_firebase_ruleset" "ruleset" {
+	files {
+		name    = "firestore.rules"
+		content = <<EOT
+rules_version = '2';
+service cloud.firestore {
+	match /databases/{database}/documents {
+		match /{document=**} {
+			allow read: if request.auth != null;
+			allow write: if false;
+		}
+	}
+}
+EOT
+	}
+
+	test_case {
+		name        = "anonymous cannot read"
+		expectation = "DENY"
+		request = jsonencode({
+			method = "get"
+			path   = "/databases/(default)/documents/users/alice"
+		})
+	}
+}
+`, context)
+}
@@ -8,19 +8,20 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func resourceFirebaseStorageRule() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceFirebaseStorageRuleCreate,
-		Read:   resourceFirebaseStorageRuleRead,
-		// Update: resourceFirebaseStorageRuleCreate,
-		Delete: resourceFirebaseStorageRuleDelete,
+		CreateContext: firebaseRulesLintWarnings("storage.rules", resourceFirebaseStorageRuleCreate),
+		Read:          resourceFirebaseStorageRuleRead,
+		UpdateContext: firebaseRulesLintWarnings("storage.rules", resourceFirebaseStorageRuleUpdate),
+		Delete:        resourceFirebaseStorageRuleDelete,
 
-		// Importer: &schema.ResourceImporter{
-		// 	State: resourceComputeHealthCheckImport,
-		// },
+		Importer: &schema.ResourceImporter{
+			State: resourceFirebaseStorageRuleImport,
+		},
 
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(4 * time.Minute),
@@ -28,29 +29,61 @@ func resourceFirebaseStorageRule() *schema.Resource {
 			Delete: schema.DefaultTimeout(4 * time.Minute),
 		},
 
-		// CustomizeDiff: rulesCustomizeDiff,
+		CustomizeDiff: customdiff.All(
+			firebaseRulesSourceSha256CustomizeDiff("storage.rules", false),
+			firebaseRulesetNameCustomizeDiff,
+			firebaseRulesCompileCustomizeDiff("storage.rules"),
+			firebaseRulesLintCustomizeDiff("storage.rules"),
+		),
 
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:        schema.TypeString,
 				Optional:    true,
 				Computed:    true,
-				ForceNew:    true,
 				Description: `name of the storage rule`,
 			},
 			"id": {
 				Type:        schema.TypeString,
 				Optional:    true,
 				Computed:    true,
-				ForceNew:    true,
 				Description: `id of the storage rule`,
 			},
 			"rule": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Computed:    true,
-				ForceNew:    true,
-				Description: `Source for the storage rule. Provided as a string with the correct rules schems`,
+				Type:             schema.TypeString,
+				Optional:         true,
+				ExactlyOneOf:     []string{"rule", "rule_file", "files"},
+				Description:      `Source for the storage rule. Provided as a string with the correct rules schems. Changes to whitespace or comments alone do not produce a diff`,
+				DiffSuppressFunc: firebaseRulesSourceDiffSuppress,
+				ValidateFunc:     validateFirebaseRulesSource,
+			},
+			"rule_file": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"rule", "rule_file", "files"},
+				Description:  `Path to a file holding the source for the storage rule, as an alternative to rule. Only the path and source_sha256 are kept in state`,
+			},
+			"files": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				ExactlyOneOf: []string{"rule", "rule_file", "files"},
+				Description:  `Source files of the storage rule, as an alternative to rule. Files are released in order as a single ruleset`,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: `Name of the source file`,
+						},
+						"content": {
+							Type:             schema.TypeString,
+							Required:         true,
+							Description:      `Content of the source file`,
+							DiffSuppressFunc: firebaseRulesSourceDiffSuppress,
+							ValidateFunc:     validateFirebaseRulesSource,
+						},
+					},
+				},
 			},
 			"project": {
 				Type:     schema.TypeString,
@@ -58,6 +91,27 @@ func resourceFirebaseStorageRule() *schema.Resource {
 				Computed: true,
 				ForceNew: true,
 			},
+			"test_case":       firebaseRulesTestCaseSchema(),
+			"retain_rulesets": firebaseRulesRetainRulesetsSchema(),
+			"on_destroy":      firebaseRulesOnDestroySchema(),
+			"source_sha256": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `SHA-256 of the released source, ignoring whitespace and comments. Changes when the source is edited in config, in rule_file or outside of Terraform`,
+			},
+			"previous_ruleset_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: `The ruleset that was released before this resource was created`,
+			},
+			"created_release": firebaseRulesCreatedReleaseSchema(),
+			"bucket": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: `The storage bucket the rule is released to. Defaults to the project's default storage bucket`,
+			},
 		},
 		UseJSONNumber: true,
 	}
@@ -70,18 +124,13 @@ func resourceFirebaseStorageRuleRead(d *schema.ResourceData, meta interface{}) e
 	if err != nil {
 		return err
 	}
-	urlReleases, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}projects/{{project}}/releases")
-	if err != nil {
-		return err
-	}
-
 	project, err := getProject(d, config)
 	if err != nil {
 		return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
 	}
 
 	// grab the existing rules - there are rules by default when the firebase account is created
-	res, err := sendRequest(config, "GET", project, urlReleases, userAgent, nil)
+	releases, err := listFirebaseRules(d, config, userAgent, "releases")
 
 	if err != nil {
 		return handleNotFoundError(err, d, fmt.Sprintf("FirebaseRules %q", d.Id()))
@@ -91,19 +140,30 @@ func resourceFirebaseStorageRuleRead(d *schema.ResourceData, meta interface{}) e
 		return fmt.Errorf("Error reading FirebaseRules: %s", err)
 	}
 
-	if res["releases"] == nil {
+	if len(releases) == 0 {
 		log.Printf("[INFO] No releases yet: %#v", d)
 		return nil
 	}
 
-	releases := res["releases"].([]interface{})
+	if err := resourceFirebaseStorageRuleSetDefaultBucket(d, config, userAgent); err != nil {
+		return err
+	}
 
-	if err := d.Set("name", flattenFirebaseRuleName(releases, "firebase.storage", d, config)); err != nil {
+	releaseName, err := replaceVars(d, config, firebaseStorageReleaseTemplate)
+	if err != nil {
+		return err
+	}
+
+	if err := d.Set("name", flattenFirebaseRuleNameForRelease(releases, releaseName, d, config)); err != nil {
 		return fmt.Errorf("Error reading FirebaseRules: %s", err)
 	}
 
 	// Set the ID now
 	d.SetId(d.Get("name").(string))
+	if d.Id() == "" {
+		log.Printf("[INFO] No %s release found: %#v", releaseName, d)
+		return nil
+	}
 
 	// now grab the actual rule text
 
@@ -119,7 +179,7 @@ func resourceFirebaseStorageRuleRead(d *schema.ResourceData, meta interface{}) e
 		return handleNotFoundError(err, d, fmt.Sprintf("FirebaseRules %q", d.Id()))
 	}
 
-	if err := d.Set("rule", flattenRuleFromSource(resStorageRule["source"], d, config)); err != nil {
+	if err := setFirebaseRulesSource(d, resStorageRule["source"]); err != nil {
 		return fmt.Errorf("Error reading FirebaseRules: %s", err)
 	}
 
@@ -129,27 +189,40 @@ func resourceFirebaseStorageRuleRead(d *schema.ResourceData, meta interface{}) e
 }
 
 func resourceFirebaseStorageRuleDelete(d *schema.ResourceData, meta interface{}) error {
-	// config := meta.(*Config)
-	// userAgent, err := generateUserAgentString(d, config.userAgent)
-	// if err != nil {
-	// 	return err
-	// }
-	// url, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}{{name}}")
-	// if err != nil {
-	// 	return err
-	// }
-
-	// project, err := getProject(d, config)
-	// if err != nil {
-	// 	return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
-	// }
-
-	// res, err := sendRequestWithTimeout(config, "DELETE", project, url, userAgent, nil, d.Timeout(schema.TimeoutDelete))
-	// if err != nil {
-	// 	return handleNotFoundError(err, d, "Rule")
-	// }
-
-	log.Printf("[INFO] Not deleting rule - new rule had been made the latest release")
+	return destroyFirebaseRulesRelease(d, meta, firebaseStorageReleaseTemplate, "storage.rules", firebaseStorageLockDownRule)
+}
+
+// firebaseStorageReleaseTemplate is the release name template for the
+// resource's bucket.
+const firebaseStorageReleaseTemplate = "projects/{{project}}/releases/firebase.storage/{{bucket}}"
+
+// firebaseStorageLockDownRule is released by on_destroy = "lock_down".
+const firebaseStorageLockDownRule = `rules_version = '2';
+service firebase.storage {
+  match /b/{bucket}/o {
+    match /{allPaths=**} {
+      allow read, write: if false;
+    }
+  }
+}
+`
+
+// resourceFirebaseStorageRuleSetDefaultBucket fills in the project's default
+// storage bucket when no bucket has been configured.
+func resourceFirebaseStorageRuleSetDefaultBucket(d *schema.ResourceData, config *Config, userAgent string) error {
+	if _, ok := d.GetOk("bucket"); ok {
+		return nil
+	}
+
+	bucket, err := getFirebaseDefaultStorageBucket(d, config, userAgent)
+	if err != nil {
+		return err
+	}
+
+	if err := d.Set("bucket", bucket); err != nil {
+		return fmt.Errorf("Error reading FirebaseRules: %s", err)
+	}
+
 	return nil
 }
 
@@ -159,86 +232,64 @@ func resourceFirebaseStorageRuleCreate(d *schema.ResourceData, meta interface{})
 	if err != nil {
 		return err
 	}
-	url, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}projects/{{project}}/rulesets")
-	if err != nil {
+
+	if err := resourceFirebaseStorageRuleSetDefaultBucket(d, config, userAgent); err != nil {
 		return err
 	}
 
-	project, err := getProject(d, config)
+	previous, err := getFirebaseReleaseRulesetName(d, meta, firebaseStorageReleaseTemplate)
 	if err != nil {
-		return fmt.Errorf("Error fetching project for FirebaseRules: %s", err)
+		return err
 	}
-
-	obj := make(map[string]interface{})
-
-	if !isEmptyValue(reflect.ValueOf(d.Get("rule"))) {
-		obj["rule"] = d.Get("rule")
-		obj["ruleType"] = "storage.rules"
+	if err := d.Set("previous_ruleset_name", previous); err != nil {
+		return fmt.Errorf("Error setting previous_ruleset_name: %s", err)
+	}
+	if err := d.Set("created_release", previous == ""); err != nil {
+		return fmt.Errorf("Error setting created_release: %s", err)
 	}
 
-	obj, err = resourceFirebaseRuleEncoder(d, meta, obj)
-	if err != nil {
+	if err := resourceFirebaseStorageRuleRelease(d, meta, d.Timeout(schema.TimeoutCreate)); err != nil {
 		return err
 	}
 
-	log.Printf("[DEBUG] Creating new Firebase Rule: %#v", obj)
-
-	res, err := sendRequestWithTimeout(config, "POST", project, url, userAgent, obj, d.Timeout(schema.TimeoutCreate))
-	if err != nil {
-		return fmt.Errorf("Error creating Firebase Rule: %s", err)
-	}
+	return resourceFirebaseStorageRuleRead(d, meta)
+}
 
-	// Store the ID now
-	d.SetId(res["name"].(string))
-	d.Set("name", res["name"].(string))
+func resourceFirebaseStorageRuleUpdate(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[DEBUG] Starting Storage Rule Update: %#v", d)
 
-	log.Printf("[DEBUG] Finished creating Firebase Rule %q: %#v", d.Id(), res)
+	if d.HasChange("rule") || d.HasChange("files") || d.HasChange("source_sha256") {
+		if err := resourceFirebaseStorageRuleRelease(d, meta, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return err
+		}
+	}
 
-	log.Printf("[DEBUG] Updating release with new Firebase Rule: %#v", d)
+	return resourceFirebaseStorageRuleRead(d, meta)
+}
 
-	urlRelease, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}projects/{{project}}/releases/firebase.storage/{{project}}.appspot.com")
+// resourceFirebaseStorageRuleRelease creates a ruleset from the current rule
+// source and makes it the live release of the resource's bucket.
+func resourceFirebaseStorageRuleRelease(d *schema.ResourceData, meta interface{}, timeout time.Duration) error {
+	rulesetName, err := createFirebaseRuleset(d, meta, "storage.rules", firebaseStorageReleaseTemplate, timeout)
 	if err != nil {
 		return err
 	}
 
-	releaseObj := make(map[string]interface{})
-	releaseName, err := replaceVars(d, config, "projects/{{project}}/releases/firebase.storage/{{project}}.appspot.com")
-	releaseObj["name"] = releaseName
-	releaseObj["ruleName"] = res["name"].(string)
+	// Store the ID now
+	d.SetId(rulesetName)
+	d.Set("name", rulesetName)
 
-	releaseObj, err = resourceFirebaseReleasePatchEncoder(d, meta, releaseObj)
-	if err != nil {
-		log.Printf("[ERROR] Cannot convert release to encoded obj: %v", err)
-		return err
-	}
+	log.Printf("[DEBUG] Updating release with new Firebase Rule: %#v", d)
 
-	log.Printf("[DEBUG] release obj: %v", releaseObj)
-	_, err = sendRequestWithTimeout(config, "PATCH", project, urlRelease, userAgent, releaseObj, d.Timeout(schema.TimeoutCreate))
-	if err != nil {
-		// release doesn't exist - try to create
-		if strings.Contains(err.Error(), "404") {
-			urlCreateRelease, err := replaceVars(d, config, "{{FirebaseRulesBasePath}}projects/{{project}}/releases")
-			if err != nil {
-				return err
-			}
-			releasePostObj := make(map[string]interface{})
-			releaseName, err := replaceVars(d, config, "projects/{{project}}/releases/firebase.storage/{{project}}.appspot.com")
-			releasePostObj["name"] = releaseName
-			releasePostObj["ruleName"] = res["name"].(string)
-			releasePostObj, err = resourceFirebaseReleasePostEncoder(d, meta, releasePostObj)
-			if err != nil {
-				log.Printf("[ERROR] Cannot convert release to encoded obj: %v", err)
-				return err
-			}
-			sendRequestWithTimeout(config, "POST", project, urlCreateRelease, userAgent, releasePostObj, d.Timeout(schema.TimeoutCreate))
-		} else {
-			return fmt.Errorf("Error creating Firebase Release: %s", err)
-		}
+	if err := releaseFirebaseRuleset(d, meta, firebaseStorageReleaseTemplate, rulesetName, timeout); err != nil {
+		return err
 	}
 
 	log.Printf("[DEBUG] Finished release with new Firebase Rule: %#v", d)
 
-	return resourceFirebaseStorageRuleRead(d, meta)
+	deleteExpiredFirebaseRulesets(d, meta.(*Config), firebaseStorageReleaseTemplate, rulesetName, timeout)
+
+	return nil
 }
 
 func flattenFirebaseRuleName(v []interface{}, ruleType string, d *schema.ResourceData, config *Config) interface{} {
@@ -249,15 +300,104 @@ func flattenFirebaseRuleName(v []interface{}, ruleType string, d *schema.Resourc
 	return nil
 }
 
-func flattenRuleFromSource(v interface{}, d *schema.ResourceData, config *Config) interface{} {
-	files := v.(map[string]interface{})["files"]
-	fd := files.([]interface{})[0]
-	content := fd.(map[string]interface{})["content"]
+// flattenFirebaseRuleNameForRelease returns the ruleset name of the release
+// whose name is exactly releaseName.
+func flattenFirebaseRuleNameForRelease(v []interface{}, releaseName string, d *schema.ResourceData, config *Config) interface{} {
+	for _, r := range v {
+		release := r.(map[string]interface{})
+		if release["name"] == releaseName {
+			return release["rulesetName"]
+		}
+	}
+	return nil
+}
 
-	return content
+func flattenFirebaseRulesFiles(v interface{}, d *schema.ResourceData, config *Config) []interface{} {
+	source, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	l, _ := source["files"].([]interface{})
+	transformed := make([]interface{}, 0, len(l))
+	for i, raw := range l {
+		file := raw.(map[string]interface{})
+		content := file["content"]
+		if s, ok := content.(string); ok && i == len(l)-1 {
+			content, _ = unmarkFirebaseRulesContent(s)
+		}
+		transformed = append(transformed, map[string]interface{}{
+			"name":    file["name"],
+			"content": content,
+		})
+	}
+	return transformed
+}
+
+// setFirebaseRulesSource stores the ruleset source in rule when it is a single
+// file, unless the resource is configured with files, and in files otherwise.
+func setFirebaseRulesSource(d *schema.ResourceData, v interface{}) error {
+	files := flattenFirebaseRulesFiles(v, d, nil)
+	if err := d.Set("source_sha256", firebaseRulesSourceSha256(files)); err != nil {
+		return err
+	}
+
+	// source read from rule_file is only tracked through source_sha256
+	if ruleFile, _ := d.Get("rule_file").(string); ruleFile != "" {
+		if err := d.Set("rule", ""); err != nil {
+			return err
+		}
+		return d.Set("files", nil)
+	}
+
+	_, useFiles := d.GetOk("files")
+	if useFiles || len(files) != 1 {
+		if err := d.Set("rule", ""); err != nil {
+			return err
+		}
+		return d.Set("files", files)
+	}
+
+	if err := d.Set("rule", files[0].(map[string]interface{})["content"]); err != nil {
+		return err
+	}
+	return d.Set("files", nil)
+}
+
+// expandFirebaseRulesFiles returns the ruleset source files from either the
+// files block or the single rule source, named after ruleType.
+func expandFirebaseRulesFiles(rule, files interface{}, ruleType interface{}) []interface{} {
+	transformed := make([]interface{}, 0)
+	if l, ok := files.([]interface{}); ok && len(l) > 0 {
+		for _, raw := range l {
+			file := raw.(map[string]interface{})
+			transformed = append(transformed, map[string]interface{}{
+				"name":    file["name"],
+				"content": file["content"],
+			})
+		}
+		return transformed
+	}
+
+	if !isEmptyValue(reflect.ValueOf(rule)) {
+		transformed = append(transformed, map[string]interface{}{
+			"name":    ruleType,
+			"content": rule,
+		})
+	}
+	return transformed
 }
 
 func getLatestRulesetByType(list []interface{}, typeOfRuleset string) interface{} {
+	release := getLatestReleaseByType(list, typeOfRuleset)
+	if release == nil {
+		return nil
+	}
+	return release["rulesetName"]
+}
+
+// getLatestReleaseByType returns the most recently created release whose name
+// matches typeOfRuleset, or nil when there is none.
+func getLatestReleaseByType(list []interface{}, typeOfRuleset string) map[string]interface{} {
 	filtered := []map[string]interface{}{}
 	for _, m := range list {
 		rulesetName := m.(map[string]interface{})["name"].(string)
@@ -277,7 +417,7 @@ func getLatestRulesetByType(list []interface{}, typeOfRuleset string) interface{
 				filtered[j]["createTime"].(string))
 			return iTime.After(jTime)
 		})
-		return filtered[0]["rulesetName"]
+		return filtered[0]
 	}
 
 	return nil
@@ -285,14 +425,9 @@ func getLatestRulesetByType(list []interface{}, typeOfRuleset string) interface{
 
 func resourceFirebaseRuleEncoder(d *schema.ResourceData, meta interface{}, obj map[string]interface{}) (map[string]interface{}, error) {
 
-	if _, ok := d.GetOk("rule"); ok {
+	if files := expandFirebaseRulesFiles(obj["rule"], obj["files"], obj["ruleType"]); len(files) > 0 {
 		rule := make(map[string]interface{})
 		source := make(map[string]interface{})
-		files := []map[string]interface{}{}
-		file := make(map[string]interface{})
-		file["content"] = obj["rule"]
-		file["name"] = obj["ruleType"]
-		files = append(files, file)
 		source["files"] = files
 		rule["source"] = source
 
@@ -320,3 +455,32 @@ func resourceFirebaseReleasePostEncoder(d *schema.ResourceData, meta interface{}
 
 	return release, nil
 }
+
+func resourceFirebaseStorageRuleImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	config := meta.(*Config)
+	if err := parseImportId([]string{
+		"^projects/(?P<project>[^/]+)/releases/firebase\\.storage/(?P<bucket>[^/]+)$",
+		"^(?P<project>[^/]+)/(?P<bucket>[^/]+)$",
+		"^(?P<project>[^/]+)$",
+	}, d, config); err != nil {
+		return nil, err
+	}
+
+	// Explicitly set virtual fields to default values on import
+	if err := d.Set("on_destroy", "keep"); err != nil {
+		return nil, fmt.Errorf("Error setting on_destroy: %s", err)
+	}
+	if err := d.Set("created_release", false); err != nil {
+		return nil, fmt.Errorf("Error setting created_release: %s", err)
+	}
+
+	if err := resourceFirebaseStorageRuleRead(d, meta); err != nil {
+		return nil, err
+	}
+
+	if d.Get("name").(string) == "" {
+		return nil, fmt.Errorf("Error importing FirebaseRules: no firebase.storage release found for bucket %q in project %q", d.Get("bucket"), d.Get("project"))
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
@@ -1,6 +1,7 @@
 package sidkik
 
 import (
+	"context"
 	"encoding/json"
 	"reflect"
 	"testing"
@@ -23,9 +24,62 @@ func TestAccFirebaseStorageRule_rule(t *testing.T) {
 				Config: testAccFirebaseStorageRule_rule(context),
 			},
 			{
-				ResourceName:      "sidkik_firebase_storage_rule.rule",
-				ImportState:       true,
-				ImportStateVerify: true,
+				ResourceName:            "sidkik_firebase_storage_rule.rule",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"previous_ruleset_name", "created_release"},
+			},
+		},
+	})
+}
+
+func TestAccFirebaseStorageRule_bucket(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{
+		"bucket": getTestProjectFromEnv() + ".appspot.com",
+	}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckFirebaseStorageRuleDestroyProducer(t),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseStorageRule_bucket(context),
+			},
+			{
+				ResourceName:            "sidkik_firebase_storage_rule.rule",
+				ImportState:             true,
+				ImportStateId:           getTestProjectFromEnv() + "/" + getTestProjectFromEnv() + ".appspot.com",
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"previous_ruleset_name", "created_release"},
+			},
+		},
+	})
+}
+
+func TestAccFirebaseStorageRule_update(t *testing.T) {
+	t.Parallel()
+
+	context := map[string]interface{}{}
+
+	vcrTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckFirebaseStorageRuleDestroyProducer(t),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFirebaseStorageRule_rule(context),
+			},
+			{
+				Config: testAccFirebaseStorageRule_ruleUpdated(context),
+			},
+			{
+				ResourceName:            "sidkik_firebase_storage_rule.rule",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"previous_ruleset_name", "created_release"},
 			},
 		},
 	})
@@ -48,6 +102,42 @@ EOT
 `, context)
 }
 
+func testAccFirebaseStorageRule_ruleUpdated(context map[string]interface{}) string {
+	return Nprintf(`
+resource "sidkik_firebase_storage_rule" "rule" {
+	rule = <<EOT
+rules_version = '2';
+service firebase.storage {
+	match /b/{bucket}/o {
+		match /{allPaths=**} {
+			allow read: if request.auth != null;
+			allow write: if false;
+		}
+	}
+}
+EOT
+}
+`, context)
+}
+
+func testAccFirebaseStorageRule_bucket(context map[string]interface{}) string {
+	return Nprintf(`
+resource "sidkik_firebase_storage_rule" "rule" {
+	bucket = "%{bucket}"
+	rule = <<EOT
+rules_version = '2';
+service firebase.storage {
+	match /b/{bucket}/o {
+		match /{allPaths=**} {
+			allow read: if request.auth != null;
+		}
+	}
+}
+EOT
+}
+`, context)
+}
+
 func testAccCheckFirebaseStorageRuleDestroyProducer(t *testing.T) func(s *terraform.State) error {
 	return func(s *terraform.State) error {
 		// for name, rs := range s.RootModule().Resources {
@@ -81,6 +171,40 @@ func testAccCheckFirebaseStorageRuleDestroyProducer(t *testing.T) func(s *terraf
 	}
 }
 
+func TestFirebaseStorageRule_ruleChangeUpdatesInPlace(t *testing.T) {
+	t.Parallel()
+
+	r := resourceFirebaseStorageRule()
+	oldRule := "service firebase.storage {\n  match /b/{bucket}/o {\n    match /{allPaths=**} {\n      allow read: if false;\n    }\n  }\n}\n"
+	newRule := "service firebase.storage {\n  match /b/{bucket}/o {\n    match /{allPaths=**} {\n      allow read: if request.auth != null;\n    }\n  }\n}\n"
+
+	state := &terraform.InstanceState{
+		ID: "projects/my-project/rulesets/abc",
+		Attributes: map[string]string{
+			"id":            "projects/my-project/rulesets/abc",
+			"name":          "projects/my-project/rulesets/abc",
+			"rule":          oldRule,
+			"bucket":        "my-project.appspot.com",
+			"on_destroy":    "keep",
+			"source_sha256": firebaseRulesSourceSha256(expandFirebaseRulesFiles(oldRule, nil, "storage.rules")),
+		},
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"rule": newRule,
+	})
+
+	diff, err := r.Diff(context.Background(), state, config, &Config{})
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if diff == nil || diff.Attributes["name"] == nil || !diff.Attributes["name"].NewComputed {
+		t.Fatalf("Diff() expected name to be recomputed, got %#v", diff)
+	}
+	if diff.RequiresNew() {
+		t.Errorf("Diff() requires replacement, want an in-place update: %#v", diff.Attributes)
+	}
+}
+
 func Test_flattenFirebaseRuleName(t *testing.T) {
 	type args struct {
 		response string
@@ -149,3 +273,78 @@ func Test_flattenFirebaseRuleName(t *testing.T) {
 		})
 	}
 }
+
+func Test_expandFirebaseRulesFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  interface{}
+		files interface{}
+		want  []interface{}
+	}{
+		{
+			name:  "rule",
+			rule:  "service firebase.storage {}",
+			files: []interface{}{},
+			want: []interface{}{
+				map[string]interface{}{"name": "storage.rules", "content": "service firebase.storage {}"},
+			},
+		},
+		{
+			name: "files",
+			rule: "",
+			files: []interface{}{
+				map[string]interface{}{"name": "helpers.rules", "content": "function isSignedIn() { return request.auth != null; }"},
+				map[string]interface{}{"name": "storage.rules", "content": "service firebase.storage {}"},
+			},
+			want: []interface{}{
+				map[string]interface{}{"name": "helpers.rules", "content": "function isSignedIn() { return request.auth != null; }"},
+				map[string]interface{}{"name": "storage.rules", "content": "service firebase.storage {}"},
+			},
+		},
+		{
+			name:  "empty",
+			rule:  "",
+			files: nil,
+			want:  []interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandFirebaseRulesFiles(tt.rule, tt.files, "storage.rules"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandFirebaseRulesFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_flattenFirebaseRulesFiles(t *testing.T) {
+	response := `{
+		"name": "projects/acct-1tcgeonjjgqi/rulesets/cf5bfeae-f139-4c2e-9d05-2805ab316a2f",
+		"source": {
+			"files": [
+				{
+					"content": "function isSignedIn() { return request.auth != null; }",
+					"name": "helpers.rules",
+					"fingerprint": "m1mDG9E0xOaSbJ6nWlTGPR2Fdm1R9ZxOiQ8Nwfn2NdA="
+				},
+				{
+					"content": "service firebase.storage {}",
+					"name": "storage.rules",
+					"fingerprint": "xZrE3EovnIRJvDm0Xy2lhK4Bkn3JfH2OQJwHUO3ZiQA="
+				}
+			]
+		},
+		"createTime": "2021-12-15T02:42:56.748909Z"
+	}`
+
+	var result map[string]interface{}
+	json.Unmarshal([]byte(response), &result)
+
+	want := []interface{}{
+		map[string]interface{}{"name": "helpers.rules", "content": "function isSignedIn() { return request.auth != null; }"},
+		map[string]interface{}{"name": "storage.rules", "content": "service firebase.storage {}"},
+	}
+	if got := flattenFirebaseRulesFiles(result["source"], nil, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("flattenFirebaseRulesFiles() = %v, want %v", got, want)
+	}
+}
@@ -367,23 +367,34 @@ func paginatedListRequest(project, baseUrl, userAgent string, config *Config, fl
 	}
 
 	ls := flattener(res)
-	pageToken, ok := res["pageToken"]
-	for ok {
-		if pageToken.(string) == "" {
-			break
+	pageToken := nextPageToken(res)
+	for pageToken != "" {
+		url, err := addQueryParams(baseUrl, map[string]string{"pageToken": pageToken})
+		if err != nil {
+			return nil, err
 		}
-		url := fmt.Sprintf("%s?pageToken=%s", baseUrl, pageToken.(string))
 		res, err = sendRequest(config, "GET", project, url, userAgent, nil)
 		if err != nil {
 			return nil, err
 		}
-		ls = append(ls, flattener(res))
-		pageToken, ok = res["pageToken"]
+		ls = append(ls, flattener(res)...)
+		pageToken = nextPageToken(res)
 	}
 
 	return ls, nil
 }
 
+// nextPageToken returns the token of the next page of a list response, or an
+// empty string on the last page.
+func nextPageToken(res map[string]interface{}) string {
+	for _, k := range []string{"nextPageToken", "pageToken"} {
+		if token, ok := res[k].(string); ok && token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
 // func getInterconnectAttachmentLink(config *Config, project, region, ic, userAgent string) (string, error) {
 // 	if !strings.Contains(ic, "/") {
 // 		icData, err := config.NewComputeClient(userAgent).InterconnectAttachments.Get(
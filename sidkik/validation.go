@@ -316,6 +316,15 @@ func validateHourlyOnly(val interface{}, key string) (warns []string, errs []err
 	return
 }
 
+// validateFirebaseRulesSource checks that rule source parses, reporting the
+// line and column of the first syntax error.
+func validateFirebaseRulesSource(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := parseFirebaseRules(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q contains invalid Firebase Rules: %s", k, err))
+	}
+	return
+}
+
 func validateRFC3339Date(v interface{}, k string) (warnings []string, errors []error) {
 	_, err := time.Parse(time.RFC3339, v.(string))
 	if err != nil {